@@ -0,0 +1,15 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+)
+
+// prints the command as a shell comment, so the wrapper shows it but does not
+// run it
+type Commenter struct {
+}
+
+func (c Commenter) Execute(message string) {
+	fmt.Println("# " + strings.ReplaceAll(message, "\n", "\n# "))
+}
@@ -0,0 +1,22 @@
+package action
+
+// the names of the actions, the run modes a command can be chosen with
+const (
+	ModePrint   = "print-command"
+	ModeCopy    = "copy-paste"
+	ModeComment = "print-only"
+)
+
+var registry = map[string]Action{
+	ModePrint:   Printer{},
+	ModeCopy:    CopyPaster{},
+	ModeComment: Commenter{},
+}
+
+// the action of the mode, printing when there is none
+func Get(mode string) Action {
+	if action, ok := registry[mode]; ok {
+		return action
+	}
+	return Printer{}
+}
@@ -0,0 +1,125 @@
+package favorites
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sebastianxyzsss/tardigrade/logger"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+	"github.com/sebastianxyzsss/tardigrade/reader"
+	"github.com/sebastianxyzsss/tardigrade/redacter"
+	"gopkg.in/yaml.v2"
+)
+
+var ll = logger.SetupLog()
+
+// one starred group or command, the path finds it where it was starred and
+// the command finds it again after it was moved
+type Favorite struct {
+	Path    string    `yaml:"path"`
+	Command string    `yaml:"command,omitempty"`
+	Time    time.Time `yaml:"time"`
+}
+
+// the content of tardifavorites.yml, in the order they were starred
+type Favorites struct {
+	Favorites []Favorite `yaml:"favorites"`
+}
+
+func newFavorite(element *parser.Element) Favorite {
+	favorite := Favorite{Path: redacter.Redact(parser.ElementPath(element)), Time: time.Now()}
+	if element.IsCommand {
+		favorite.Command = redacter.Redact(parser.CommandString(element))
+	}
+	return favorite
+}
+
+func Load() (*Favorites, error) {
+	userTardiFavorites, err := reader.GetFavoritesPath()
+	if err != nil {
+		return nil, err
+	}
+	return parse(reader.GetFileAsString(*userTardiFavorites))
+}
+
+func parse(content *string) (*Favorites, error) {
+	f := &Favorites{Favorites: []Favorite{}}
+	if content == nil {
+		ll.Debug().Msg("favorites do not exist yet")
+		return f, nil
+	}
+	err := yaml.Unmarshal([]byte(*content), f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read favorites: %w", err)
+	}
+	return f, nil
+}
+
+// the position of the favorite of element, or -1
+func (f *Favorites) find(element *parser.Element) int {
+	favorite := newFavorite(element)
+	for i, old := range f.Favorites {
+		if old.Path == favorite.Path || (favorite.Command != "" && old.Command == favorite.Command) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *Favorites) Has(element *parser.Element) bool {
+	return f != nil && element != nil && f.find(element) >= 0
+}
+
+// stars the element or takes the star away, under the file lock, it returns
+// the favorites as saved and if the element is starred now
+func Toggle(element *parser.Element) (*Favorites, bool, error) {
+	userTardiFavorites, err := reader.GetFavoritesPath()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var saved *Favorites
+	starred := false
+	err = reader.UpdateFile(*userTardiFavorites, func(current *string) (string, error) {
+		f, err := parse(current)
+		if err != nil {
+			return "", err
+		}
+		if i := f.find(element); i >= 0 {
+			f.Favorites = append(f.Favorites[:i], f.Favorites[i+1:]...)
+		} else {
+			f.Favorites = append(f.Favorites, newFavorite(element))
+			starred = true
+		}
+		saved = f
+		y, err := yaml.Marshal(f)
+		return string(y), err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	message := "favorites: unstarred " + redacter.Redact(parser.ElementPath(element))
+	if starred {
+		message = "favorites: starred " + redacter.Redact(parser.ElementPath(element))
+	}
+	err = reader.CommitChange(message, *userTardiFavorites)
+	if err != nil {
+		ll.Warn().Err(err).Msg("unable to version the change")
+	}
+	return saved, starred, nil
+}
+
+// the starred elements still in the tree
+func (f *Favorites) Elements(index parser.Index) []*parser.Element {
+	elements := make([]*parser.Element, 0)
+	if f == nil {
+		return elements
+	}
+	for _, favorite := range f.Favorites {
+		if element := index.Find(favorite.Path, favorite.Command); element != nil {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
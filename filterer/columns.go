@@ -0,0 +1,95 @@
+package filterer
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+// how the options are laid out
+const (
+	LayoutList    = "list"    // the current group only
+	LayoutColumns = "columns" // parent, current group and highlighted child side by side
+)
+
+func IsValidLayout(layout string) bool {
+	return layout == LayoutList || layout == LayoutColumns || layout == LayoutTree
+}
+
+// widths of the parent and child columns, the current group takes the rest
+func (m model) columnWidths() (int, int) {
+	width := m.width
+	if width <= 0 {
+		width = 120
+	}
+	return width / 4, width * 3 / 8
+}
+
+// the group we came from, with the group we are in highlighted
+func (m model) renderParentColumn(width int, height int) string {
+	style := lipgloss.NewStyle().Width(width).MaxWidth(width).Height(height).MaxHeight(height)
+	if len(m.stack) == 0 {
+		return style.Render("")
+	}
+	previous := m.stack[len(m.stack)-1]
+
+	// keep the highlighted group in sight
+	start := 0
+	if previous.cursor >= height {
+		start = previous.cursor - height + 1
+	}
+
+	lines := make([]string, 0)
+	for i := start; i < len(previous.matches) && len(lines) < height; i++ {
+		child := previous.element.ChildrenSorted[previous.matches[i].Index]
+		content := parser.TruncateString(child.Content, width-4)
+		if i == previous.cursor {
+			lines = append(lines, m.indicatorStyle.Render(m.indicator)+" "+m.matchStyle.Render(content))
+		} else {
+			lines = append(lines, "  "+m.textStyle.Render(content))
+		}
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// what is inside the highlighted option, its children or the command itself
+func (m model) renderChildColumn(element *parser.Element, width int, height int) string {
+	style := lipgloss.NewStyle().Width(width).MaxWidth(width).Height(height).MaxHeight(height)
+	if element == nil {
+		return style.Render("")
+	}
+	if element.IsCommand {
+		return style.Render(m.renderPreview(element, width, height))
+	}
+
+	lines := make([]string, 0)
+	for _, child := range element.ChildrenSorted {
+		if len(lines) >= height {
+			break
+		}
+		if !elementPassesPostCriteria(child) {
+			continue
+		}
+		prefix := "  "
+		if !child.IsCommand {
+			prefix = m.indicatorStyle.Render("▸ ")
+		}
+		lines = append(lines, prefix+m.textStyle.Render(parser.TruncateString(child.Content, width-4)))
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderColumns(listView string, element *parser.Element) string {
+	parentWidth, childWidth := m.columnWidths()
+	height := m.viewport.Height
+	separator := m.indicatorStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", height), "\n"))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		m.renderParentColumn(parentWidth, height),
+		separator,
+		lipgloss.NewStyle().Width(m.viewport.Width).Render(listView),
+		separator,
+		lipgloss.NewStyle().PaddingLeft(1).Render(m.renderChildColumn(element, childWidth-1, height)),
+	)
+}
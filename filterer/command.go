@@ -9,21 +9,23 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/mattn/go-isatty"
-	"github.com/sahilm/fuzzy"
 	"github.com/sebastianxyzsss/tardigrade/globals"
 	"github.com/sebastianxyzsss/tardigrade/parser"
-	"github.com/sebastianxyzsss/tardigrade/utils"
 
 	"github.com/charmbracelet/gum/style"
 )
 
 // Run provides a shell script interface for filtering through options, powered
-// by the textinput bubble.
-func (o Options) Run(element *parser.Element) (*parser.Element, error) {
+// by the textinput bubble. One program is used for the whole navigation, going
+// into groups and back, it returns the chosen command and the action it was
+// chosen for.
+func (o Options) Run(element *parser.Element) (*parser.Element, string, error) {
 
 	i := textinput.New()
-	i.Focus()
+	// with keys to focus the filter, the keys to move around come first
+	if !o.Keys.Focus.Enabled() {
+		i.Focus()
+	}
 
 	i.Prompt = o.Prompt
 	i.PromptStyle = o.PromptStyle.ToLipgloss()
@@ -32,10 +34,15 @@ func (o Options) Run(element *parser.Element) (*parser.Element, error) {
 
 	v := viewport.New(o.Width, o.Height)
 
+	root := element
+	if o.Flat {
+		element = parser.Flatten(root)
+	}
+
 	choices := getChoices(element)
 
 	if len(choices) == 0 {
-		return nil, errors.New("no options provided, see `gum filter --help`")
+		return nil, "", errors.New("no options provided, see `gum filter --help`")
 	}
 
 	options := []tea.ProgramOption{tea.WithOutput(os.Stderr)}
@@ -43,113 +50,59 @@ func (o Options) Run(element *parser.Element) (*parser.Element, error) {
 		options = append(options, tea.WithAltScreen())
 	}
 
-	var matches []fuzzy.Match
 	if o.Value != "" {
 		i.SetValue(o.Value)
 	}
-	switch {
-	case o.Value != "" && o.Fuzzy:
-		matches = fuzzy.Find(o.Value, choices)
-	case o.Value != "" && !o.Fuzzy:
-		matches = exactMatches(o.Value, choices)
-	default:
-		matches = matchAll(choices)
-	}
 
-	if o.NoLimit {
-		o.Limit = len(choices)
+	m := model{
+		root:           root,
+		element:        element,
+		choices:        choices,
+		stack:          make([]frame, 0),
+		indicator:      o.Indicator,
+		header:         o.Header,
+		textinput:      i,
+		viewport:       &v,
+		indicatorStyle: o.IndicatorStyle.ToLipgloss(),
+		matchStyle:     o.MatchStyle.ToLipgloss(),
+		headerStyle:    o.HeaderStyle.ToLipgloss(),
+		textStyle:      o.TextStyle.ToLipgloss(),
+		height:         o.Height,
+		reverse:        o.Reverse,
+		fuzzy:          o.Fuzzy,
+		ranking:        o.Ranking,
+		frecency:       o.Frecency,
+		preview:        o.Preview,
+		usage:          o.Usage,
+		layout:         o.Layout,
+		flat:           o.Flat,
+		scope:          o.Scope,
+		expanded:       make(map[*parser.Element]bool),
+		tagsSelected:   make(map[string]bool),
+		favorite:       o.Favorite,
+		isFavorite:     o.IsFavorite,
+		keys:           o.Keys,
 	}
+	m.help = m.newHelp()
+	m.updateMatches()
 
-	p := tea.NewProgram(model{
-		element:               element,
-		choices:               choices,
-		indicator:             o.Indicator,
-		matches:               matches,
-		header:                o.Header,
-		textinput:             i,
-		viewport:              &v,
-		indicatorStyle:        o.IndicatorStyle.ToLipgloss(),
-		selectedPrefixStyle:   o.SelectedPrefixStyle.ToLipgloss(),
-		selectedPrefix:        o.SelectedPrefix,
-		unselectedPrefixStyle: o.UnselectedPrefixStyle.ToLipgloss(),
-		unselectedPrefix:      o.UnselectedPrefix,
-		matchStyle:            o.MatchStyle.ToLipgloss(),
-		headerStyle:           o.HeaderStyle.ToLipgloss(),
-		textStyle:             o.TextStyle.ToLipgloss(),
-		height:                o.Height,
-		selected:              make(map[string]struct{}),
-		limit:                 o.Limit,
-		reverse:               o.Reverse,
-		fuzzy:                 o.Fuzzy,
-	}, options...)
+	p := tea.NewProgram(m, options...)
 
 	tm, err := p.Run()
 	if err != nil {
-		return nil, fmt.Errorf("unable to run filter: %w", err)
+		return nil, "", fmt.Errorf("unable to run filter: %w", err)
 	}
-	m := tm.(model)
+	m = tm.(model)
 	if m.aborted {
-		return nil, ErrAborted
-	}
-
-	isTTY := isatty.IsTerminal(os.Stdout.Fd())
-
-	var chosen *parser.Element
-
-	// allSelections contains values only if limit is greater
-	// than 1 or if flag --no-limit is passed, hence there is
-	// no need to further checks
-	if len(m.selected) > 0 {
-		for k := range m.selected {
-			if isTTY {
-				globals.RunAction.Execute(k)
-			} else {
-				globals.RunAction.Execute(Strip(k))
-			}
-		}
-	} else if len(m.matches) > m.cursor && m.cursor >= 0 {
-		index := m.cursor
-		if isTTY {
-			content := m.matches[index].Str
-			if m.left {
-				if m.element.Parent != nil {
-					chosen = m.element.Parent
-				} else {
-					chosen = m.element
-				}
-			} else {
-				chosen = element.Children[content]
-			}
-			if chosen.IsCommand {
-				if parser.DoesElementHaveCommandTag(chosen.Parent) {
-					content = utils.ReplaceContentWithChoices(chosen.Parent.Content, content)
-				}
-				globals.RunAction.Execute(content)
-			}
-		} else {
-			content := Strip(m.matches[index].Str)
-			if m.left {
-				if m.element.Parent != nil {
-					chosen = m.element.Parent
-				} else {
-					chosen = m.element
-				}
-			} else {
-				chosen = element.Children[content]
-			}
-			if chosen.IsCommand {
-				if parser.DoesElementHaveCommandTag(chosen.Parent) {
-					content = utils.ReplaceContentWithChoices(chosen.Parent.Content, content)
-				}
-				globals.RunAction.Execute(content)
-			}
-		}
+		return nil, "", ErrAborted
 	}
 
 	if !o.Strict && len(m.textinput.Value()) != 0 && len(m.matches) == 0 {
 		fmt.Println(m.textinput.Value())
 	}
-	return chosen, nil
+	// one element is chosen, it goes back to the caller, which prompts for
+	// its placeholders and records it
+	return m.chosen, m.action, nil
 }
 
 func getChoices(element *parser.Element) []string {
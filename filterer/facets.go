@@ -0,0 +1,164 @@
+package filterer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+// one tag of the options shown, with how many of them have it
+type facet struct {
+	tag   string
+	count int
+}
+
+// the tags of the element, own and inherited, once each and by the name
+// aliases stand for, with the namespaces they are in, so env/prod also counts
+// for env
+func tagSet(element *parser.Element) map[string]bool {
+	tags := make(map[string]bool, len(element.Tags))
+	for _, tag := range element.Tags {
+		names := strings.Split(parser.CanonicalTag(tag), "/")
+		for i := range names {
+			tags[strings.Join(names[:i+1], "/")] = true
+		}
+	}
+	return tags
+}
+
+// if the selected tags are used to narrow the options
+func (m model) narrowing() bool {
+	return len(m.tagsSelected) > 0
+}
+
+// counts the tags of the matched elements and keeps the ones with the selected
+// tags, all of them or any of them
+func (m *model) narrowByTags(matches []fuzzy.Match, elements []*parser.Element) []fuzzy.Match {
+	counts := make(map[string]int)
+	for _, match := range matches {
+		for tag := range tagSet(elements[match.Index]) {
+			counts[tag]++
+		}
+	}
+	// selected tags stay in the panel so they can be unselected
+	for tag := range m.tagsSelected {
+		if _, ok := counts[tag]; !ok {
+			counts[tag] = 0
+		}
+	}
+
+	m.facets = make([]facet, 0, len(counts))
+	for tag, count := range counts {
+		m.facets = append(m.facets, facet{tag: tag, count: count})
+	}
+	sort.Slice(m.facets, func(i, j int) bool {
+		if m.facets[i].count != m.facets[j].count {
+			return m.facets[i].count > m.facets[j].count
+		}
+		return m.facets[i].tag < m.facets[j].tag
+	})
+	m.facetCursor = clamp(0, len(m.facets)-1, m.facetCursor)
+
+	if !m.narrowing() {
+		return matches
+	}
+
+	narrowed := make([]fuzzy.Match, 0, len(matches))
+	for _, match := range matches {
+		if m.hasSelectedTags(elements[match.Index]) {
+			narrowed = append(narrowed, match)
+		}
+	}
+	return narrowed
+}
+
+func (m model) hasSelectedTags(element *parser.Element) bool {
+	tags := tagSet(element)
+	for tag := range m.tagsSelected {
+		if tags[tag] && !m.tagsAll {
+			return true
+		}
+		if !tags[tag] && m.tagsAll {
+			return false
+		}
+	}
+	return m.tagsAll
+}
+
+// keys while the tag panel is open, the list is narrowed as tags are toggled
+func (m *model) updateTagPanel(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, m.keys.TagClose, m.keys.Tags):
+		m.tagPanel = false
+	case key.Matches(msg, m.keys.TagLeft, m.keys.Up):
+		m.facetCursor = clamp(0, len(m.facets)-1, m.facetCursor-1)
+	case key.Matches(msg, m.keys.TagRight, m.keys.Down):
+		m.facetCursor = clamp(0, len(m.facets)-1, m.facetCursor+1)
+	case key.Matches(msg, m.keys.TagCheck):
+		if m.facetCursor >= len(m.facets) {
+			return
+		}
+		tag := m.facets[m.facetCursor].tag
+		if m.tagsSelected[tag] {
+			delete(m.tagsSelected, tag)
+		} else {
+			m.tagsSelected[tag] = true
+		}
+		m.refresh()
+	case key.Matches(msg, m.keys.TagAll):
+		m.tagsAll = !m.tagsAll
+		m.refresh()
+	}
+}
+
+// the selected tags joined by how they narrow, for the header
+func (m model) selectedTags() string {
+	tags := make([]string, 0, len(m.tagsSelected))
+	for tag := range m.tagsSelected {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	separator := "|"
+	if m.tagsAll {
+		separator = "+"
+	}
+	return strings.Join(tags, separator)
+}
+
+// the tags of the options shown with their counts, the selected ones checked
+func (m model) renderTagPanel() string {
+	mode := "any"
+	if m.tagsAll {
+		mode = "all"
+	}
+
+	parts := make([]string, len(m.facets))
+	for i, f := range m.facets {
+		box := "[ ] "
+		if m.tagsSelected[f.tag] {
+			box = "[x] "
+		}
+		part := box + f.tag + " " + strconv.Itoa(f.count)
+		if i == m.facetCursor {
+			part = m.indicatorStyle.Render(m.indicator + part)
+		} else {
+			part = " " + part
+		}
+		parts[i] = part
+	}
+
+	panel := m.headerStyle.Render("TAGS ("+mode+"):") + " " + strings.Join(parts, "  ")
+	if len(m.facets) == 0 {
+		panel = m.headerStyle.Render("TAGS:") + " none"
+	}
+	if m.width > 0 {
+		panel = lipgloss.NewStyle().Width(m.width).Render(panel)
+	}
+	return panel
+}
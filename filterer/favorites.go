@@ -0,0 +1,39 @@
+package filterer
+
+import "github.com/sebastianxyzsss/tardigrade/parser"
+
+// stars the element or takes the star away, it returns if it is starred now
+type FavoriteFunc func(element *parser.Element) (bool, error)
+
+// if the element is starred
+type StarredFunc func(element *parser.Element) bool
+
+const favoriteMarker = "★ "
+
+func (m *model) toggleFavorite() {
+	element := m.highlighted()
+	if element == nil || element.Virtual || m.favorite == nil {
+		return
+	}
+
+	starred, err := m.favorite(element)
+	if err != nil {
+		m.notice = "unable to save the favorites: " + err.Error()
+		return
+	}
+	m.notice = "unstarred " + element.Content
+	if starred {
+		m.notice = "starred " + element.Content
+	}
+
+	// the favorites group may have changed, or appeared
+	m.choices = getChoices(m.element)
+	m.updateMatches()
+}
+
+func (m model) favoriteMarker(element *parser.Element) string {
+	if m.isFavorite != nil && m.isFavorite(element) {
+		return favoriteMarker
+	}
+	return ""
+}
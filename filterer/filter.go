@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -24,32 +25,64 @@ import (
 	"github.com/sebastianxyzsss/tardigrade/parser"
 )
 
+// one level of the navigation, kept while inside one of its groups so going
+// back shows it as it was left
+type frame struct {
+	element    *parser.Element
+	choices    []string
+	matches    []fuzzy.Match
+	highlights map[*parser.Element]fieldMatches
+	facets     []facet
+	cursor     int
+	yOffset    int
+	query      string
+}
+
 type model struct {
-	textinput             textinput.Model
-	viewport              *viewport.Model
-	element               *parser.Element
-	choices               []string
-	matches               []fuzzy.Match
-	cursor                int
-	header                string
-	selected              map[string]struct{}
-	limit                 int
-	numSelected           int
-	indicator             string
-	selectedPrefix        string
-	unselectedPrefix      string
-	height                int
-	aborted               bool
-	quitting              bool
-	left                  bool
-	headerStyle           lipgloss.Style
-	matchStyle            lipgloss.Style
-	textStyle             lipgloss.Style
-	indicatorStyle        lipgloss.Style
-	selectedPrefixStyle   lipgloss.Style
-	unselectedPrefixStyle lipgloss.Style
-	reverse               bool
-	fuzzy                 bool
+	textinput      textinput.Model
+	viewport       *viewport.Model
+	root           *parser.Element
+	element        *parser.Element
+	choices        []string
+	matches        []fuzzy.Match
+	highlights     map[*parser.Element]fieldMatches
+	cursor         int
+	stack          []frame
+	chosen         *parser.Element
+	action         string
+	header         string
+	indicator      string
+	height         int
+	aborted        bool
+	quitting       bool
+	headerStyle    lipgloss.Style
+	matchStyle     lipgloss.Style
+	textStyle      lipgloss.Style
+	indicatorStyle lipgloss.Style
+	reverse        bool
+	fuzzy          bool
+	ranking        string
+	frecency       FrecencyFunc
+	preview        string
+	usage          UsageFunc
+	layout         string
+	flat           bool
+	scope          string
+	rows           []treeRow
+	expanded       map[*parser.Element]bool
+	treeSize       int
+	tagPanel       bool
+	tagsSelected   map[string]bool
+	tagsAll        bool
+	facets         []facet
+	facetCursor    int
+	favorite       FavoriteFunc
+	isFavorite     StarredFunc
+	notice         string
+	keys           KeyMap
+	showHelp       bool
+	help           help.Model
+	width          int
 
 	// FooterFunc func(m Model, obj interface{}, gdIndex int) string
 }
@@ -66,7 +99,6 @@ func (m model) View() string {
 	description := m.headerStyle.Render("MODE:") + " " + globals.RunMode + " " + m.headerStyle.Render("DESC:") + " "
 	footer := m.headerStyle.Render("OPTS:") + m.indicatorStyle.Render(" | ")
 	index := 0
-	content2 := ""
 
 	// For reverse layout, if the number of matches is less than the viewport
 	// height, we need to offset the matches so that the first match is at the
@@ -90,19 +122,11 @@ func (m model) View() string {
 		if i == m.cursor {
 			s.WriteString(m.indicatorStyle.Render(m.indicator))
 			index = i
-			content2 = match.Str
 		} else {
 			s.WriteString(strings.Repeat(" ", runewidth.StringWidth(m.indicator)))
 		}
 
-		// If there are multiple selections mark them, otherwise leave an empty space
-		if _, ok := m.selected[match.Str]; ok {
-			s.WriteString(m.selectedPrefixStyle.Render(m.selectedPrefix))
-		} else if m.limit > 1 {
-			s.WriteString(m.unselectedPrefixStyle.Render(m.unselectedPrefix))
-		} else {
-			s.WriteString(" ")
-		}
+		s.WriteString(" ")
 
 		// For this match, there are a certain number of characters that have
 		// caused the match. i.e. fuzzy matching.
@@ -142,27 +166,52 @@ func (m model) View() string {
 
 	description = description + "#" + strconv.Itoa(index) + " "
 
-	chosenElement := m.element.Children[content2]
+	chosenElement := m.highlighted()
 
 	if chosenElement != nil {
 
-		tags := chosenElement.Tags
-		if len(tags) > 0 {
-			tagsStr = " | " + m.headerStyle.Render("TAGS: ") + strings.Join(tags, ", ")
+		if len(chosenElement.Tags) > 0 {
+			tagsStr = " | " + m.headerStyle.Render("TAGS: ") + m.renderTags(chosenElement, ", ")
 		}
 
-		description = description + m.indicatorStyle.Render("> ") + chosenElement.Description + tagsStr
+		description = description + m.indicatorStyle.Render("> ") + m.favoriteMarker(chosenElement) + m.renderHighlighted(chosenElement.Description, m.highlights[chosenElement].description) + tagsStr
 
 		childKeys := chosenElement.ChildKeys
 		footer = footer + strings.Join([]string(*childKeys), m.indicatorStyle.Render(" | ")) + m.indicatorStyle.Render(" | ")
 	}
 
 	// View the input and the filtered choices
-	header := m.headerStyle.Render(m.header)
+	header := m.headerStyle.Render(m.header+" "+parser.Breadcrumb(m.element, " › ")) + "  " +
+		m.indicatorStyle.Render(strconv.Itoa(len(m.matches))+"/"+strconv.Itoa(m.total())) + "  " +
+		m.textinput.PromptStyle.Render(m.filterMode())
+	if m.notice != "" {
+		header = header + "  " + m.indicatorStyle.Render(m.notice)
+	}
 	header = header + "\n" + m.textinput.PromptStyle.Render("~~~~~~~~~~~~~~~~")
 
+	listView := m.viewport.View()
+	switch {
+	case m.layout == LayoutColumns:
+		// the children are in the last column, the footer is not needed
+		listView = m.renderColumns(listView, chosenElement)
+		footer = ""
+	case m.preview == PreviewSide:
+		listView = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.viewport.Width).Render(listView), " ", m.renderPreview(chosenElement, m.previewWidth(), m.viewport.Height))
+		footer = ""
+	case m.preview == PreviewBottom:
+		// the preview lists the children, the footer is not needed
+		footer = m.renderPreview(chosenElement, m.previewWidth(), previewBottomHeight)
+	}
+	if m.tagPanel {
+		footer = m.renderTagPanel()
+	}
+	if m.showHelp {
+		listView = lipgloss.NewStyle().Height(m.viewport.Height).Render(m.renderHelp())
+	}
+	hint := m.renderHint()
+
 	if m.reverse {
-		view := m.viewport.View() + "\n" + m.textinput.View() + "\n" + description + "\n" + footer
+		view := listView + "\n" + m.textinput.View() + "\n" + description + "\n" + footer + "\n" + hint
 		if m.header != "" {
 			return lipgloss.JoinVertical(lipgloss.Left, view, header)
 		}
@@ -170,7 +219,11 @@ func (m model) View() string {
 		return view
 	}
 
-	view := m.textinput.View() + "\n" + m.viewport.View() + "\n" + description + "\n" + footer
+	view := m.textinput.View() + "\n" + listView + "\n" + description
+	if footer != "" {
+		view = view + "\n" + footer
+	}
+	view = view + "\n" + hint
 	if m.header != "" {
 		return lipgloss.JoinVertical(lipgloss.Left, header, view)
 	}
@@ -190,46 +243,90 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.header != "" {
 			m.viewport.Height = m.viewport.Height - lipgloss.Height(m.headerStyle.Render(m.header))
 		}
+		m.width = msg.Width
+		m.help.Width = msg.Width
 		m.viewport.Width = msg.Width
+		if m.layout == LayoutColumns {
+			parentWidth, childWidth := m.columnWidths()
+			m.viewport.Width = msg.Width - parentWidth - childWidth - 2
+		} else if m.preview == PreviewSide {
+			m.viewport.Width = msg.Width - m.previewWidth() - 1
+		}
 		if m.reverse {
 			m.viewport.YOffset = clamp(0, len(m.matches), len(m.matches)-m.viewport.Height)
 		}
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "ctrl+z", "esc":
+		m.notice = ""
+		if m.tagPanel && msg.String() != "ctrl+c" && msg.String() != "ctrl+z" {
+			m.updateTagPanel(msg)
+			break
+		}
+		if m.showHelp {
+			m.showHelp = false
+			if !m.pressed(msg, m.keys.Quit) {
+				break
+			}
+		}
+		switch {
+		case m.pressed(msg, m.keys.Quit):
 			m.aborted = true
 			m.quitting = true
-			m.left = false
-			return m, tea.Quit
-		case "left":
-			m.quitting = true
-			m.left = true
-			return m, tea.Quit
-		case "enter", "right":
-			m.quitting = true
-			m.left = false
 			return m, tea.Quit
-		case "ctrl+n", "ctrl+j", "down":
-			m.CursorDown()
-		case "ctrl+p", "ctrl+k", "up":
-			m.CursorUp()
-		case "tab":
-			if m.limit == 1 {
-				break // no op
+		case m.pressed(msg, m.keys.Back):
+			if m.layout == LayoutTree {
+				m.treeLeft()
+				break
 			}
-			m.ToggleSelection()
-			m.CursorDown()
-		case "shift+tab":
-			if m.limit == 1 {
-				break // no op
+			m.back()
+		case m.pressed(msg, m.keys.Select):
+			if m.layout == LayoutTree && !m.treeEnter() {
+				break
+			}
+			if m.choose() {
+				return m, tea.Quit
+			}
+		case m.pressed(msg, m.keys.Copy):
+			if m.pick(ActionCopy) {
+				return m, tea.Quit
+			}
+		case m.pressed(msg, m.keys.Exec):
+			if m.pick(ActionExec) {
+				return m, tea.Quit
+			}
+		case m.pressed(msg, m.keys.Print):
+			if m.pick(ActionPrint) {
+				return m, tea.Quit
 			}
-			m.ToggleSelection()
+		case m.pressed(msg, m.keys.Edit):
+			if m.pick(ActionEdit) {
+				return m, tea.Quit
+			}
+		case m.pressed(msg, m.keys.ToggleMode):
+			m.toggleFlat()
+		case m.pressed(msg, m.keys.Scope):
+			m.switchScope()
+		case m.pressed(msg, m.keys.Tags):
+			m.tagPanel = true
+		case m.pressed(msg, m.keys.Favorite):
+			m.toggleFavorite()
+		case m.pressed(msg, m.keys.Down):
+			m.CursorDown()
+		case m.pressed(msg, m.keys.Up):
 			m.CursorUp()
-		case "ctrl+@":
-			if m.limit == 1 {
-				break // no op
+		case m.pressed(msg, m.keys.PageDown):
+			for i := 0; i < max(1, m.viewport.Height); i++ {
+				m.CursorDown()
+			}
+		case m.pressed(msg, m.keys.PageUp):
+			for i := 0; i < max(1, m.viewport.Height); i++ {
+				m.CursorUp()
 			}
-			m.ToggleSelection()
+		case m.pressed(msg, m.keys.Help):
+			m.showHelp = true
+		case m.pressed(msg, m.keys.Focus) && !m.textinput.Focused():
+			cmd = m.textinput.Focus()
+		case m.pressed(msg, m.keys.Unfocus) && m.textinput.Focused():
+			m.textinput.Blur()
 		default:
 			m.textinput, cmd = m.textinput.Update(msg)
 
@@ -244,17 +341,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// A character was entered, this likely means that the text input has
 			// changed. This suggests that the matches are outdated, so update them.
-			if m.fuzzy {
-				m.matches = fuzzy.Find(m.textinput.Value(), m.choices)
-			} else {
-				m.matches = exactMatches(m.textinput.Value(), m.choices)
-			}
-
-			// If the search field is empty, let's not display the matches
-			// (none), but rather display all possible choices.
-			if m.textinput.Value() == "" {
-				m.matches = matchAll(m.choices)
-			}
+			m.updateMatches()
 
 			// For reverse layout, we need to offset the viewport so that the
 			// it remains at a constant position relative to the cursor.
@@ -271,6 +358,172 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// how many options there are without a filter
+func (m model) total() int {
+	if m.layout == LayoutTree {
+		return m.treeSize
+	}
+	return len(m.choices)
+}
+
+// the launch flags that shape the options, if they are flat and how the input
+// matches them
+func (m model) filterMode() string {
+	modes := make([]string, 0)
+	switch globals.FilterAction {
+	case globals.FilterTags:
+		modes = append(modes, "-t "+strings.Join(globals.FilterStrings, ","))
+	case globals.FilterAnything:
+		modes = append(modes, "-a "+strings.Join(globals.FilterStrings, ","))
+	case globals.FilterQuery:
+		modes = append(modes, "-q "+strings.Join(globals.FilterStrings, ","))
+	case globals.FilterFiles:
+		modes = append(modes, "-s")
+	}
+	if m.flat {
+		modes = append(modes, "flat")
+	}
+	modes = append(modes, "in:"+m.scope)
+	if m.narrowing() {
+		modes = append(modes, "tags:"+m.selectedTags())
+	}
+	if m.fuzzy {
+		modes = append(modes, "fuzzy")
+	} else {
+		modes = append(modes, "exact")
+	}
+	return "[" + strings.Join(modes, " ") + "]"
+}
+
+func (m *model) updateMatches() {
+	if m.layout == LayoutTree {
+		m.updateTreeMatches()
+		return
+	}
+
+	if m.textinput.Value() == "" {
+		// If the search field is empty, let's not display the matches
+		// (none), but rather display all possible choices.
+		m.matches = matchAll(m.choices)
+		m.highlights = nil
+	} else {
+		m.matches = m.findMatches(m.textinput.Value(), m.element.ChildrenSorted)
+	}
+	m.matches = m.narrowByTags(m.matches, m.element.ChildrenSorted)
+
+	m.matches = rankMatches(m.matches, m.element, m.ranking, m.textinput.Value() != "", m.frecency)
+}
+
+// the element under the cursor
+func (m model) highlighted() *parser.Element {
+	if m.cursor < 0 || m.cursor >= len(m.matches) {
+		return nil
+	}
+	index := m.matches[m.cursor].Index
+	if m.layout == LayoutTree {
+		if index < 0 || index >= len(m.rows) {
+			return nil
+		}
+		return m.rows[index].element
+	}
+	if index < 0 || index >= len(m.element.ChildrenSorted) {
+		return nil
+	}
+	return m.element.ChildrenSorted[index]
+}
+
+// picks the highlighted option, a command ends the program and a group is
+// entered, it returns if the program has to quit
+func (m *model) choose() bool {
+	chosen := m.highlighted()
+	if chosen == nil {
+		return false
+	}
+	if chosen.IsCommand {
+		m.chosen = chosen
+		m.action = ActionSelect
+		m.quitting = true
+		return true
+	}
+	m.enter(chosen)
+	return false
+}
+
+// picks the highlighted command for an action other than select, or the
+// highlighted group to edit, it returns if the program has to quit
+func (m *model) pick(action string) bool {
+	chosen := m.highlighted()
+	if chosen == nil {
+		return false
+	}
+	if action == ActionEdit && chosen.Source == "" {
+		m.notice = chosen.Content + " is not from a file"
+		return false
+	}
+	if action != ActionEdit && !chosen.IsCommand {
+		return false
+	}
+	m.chosen = chosen
+	m.action = action
+	m.quitting = true
+	return true
+}
+
+// goes into a group, remembering where we were
+func (m *model) enter(element *parser.Element) {
+	choices := getChoices(element)
+	if len(choices) == 0 {
+		return
+	}
+
+	m.stack = append(m.stack, frame{
+		element:    m.element,
+		choices:    m.choices,
+		matches:    m.matches,
+		highlights: m.highlights,
+		facets:     m.facets,
+		cursor:     m.cursor,
+		yOffset:    m.viewport.YOffset,
+		query:      m.textinput.Value(),
+	})
+
+	m.element = element
+	m.choices = choices
+	m.cursor = 0
+	m.viewport.YOffset = 0
+	m.textinput.SetValue("")
+	m.updateMatches()
+
+	if m.reverse {
+		m.viewport.YOffset = clamp(0, len(m.matches), len(m.matches)-m.viewport.Height)
+	}
+}
+
+// goes back to where we were before entering the current group
+func (m *model) back() {
+	if len(m.stack) == 0 {
+		return
+	}
+	previous := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+
+	m.element = previous.element
+	m.choices = previous.choices
+	m.matches = previous.matches
+	m.highlights = previous.highlights
+	m.facets = previous.facets
+	m.cursor = previous.cursor
+	m.viewport.YOffset = previous.yOffset
+	m.textinput.SetValue(previous.query)
+	m.textinput.CursorEnd()
+
+	// a virtual group may have changed while we were away
+	if choices := getChoices(m.element); len(choices) != len(m.choices) {
+		m.choices = choices
+		m.updateMatches()
+	}
+}
+
 func (m *model) CursorUp() {
 	if m.reverse {
 		m.cursor = clamp(0, len(m.matches)-1, m.cursor+1)
@@ -299,20 +552,10 @@ func (m *model) CursorDown() {
 	}
 }
 
-func (m *model) ToggleSelection() {
-	if _, ok := m.selected[m.matches[m.cursor].Str]; ok {
-		delete(m.selected, m.matches[m.cursor].Str)
-		m.numSelected--
-	} else if m.numSelected < m.limit {
-		m.selected[m.matches[m.cursor].Str] = struct{}{}
-		m.numSelected++
-	}
-}
-
 func matchAll(options []string) []fuzzy.Match {
 	matches := make([]fuzzy.Match, len(options))
 	for i, option := range options {
-		matches[i] = fuzzy.Match{Str: option}
+		matches[i] = fuzzy.Match{Str: option, Index: i}
 	}
 	return matches
 }
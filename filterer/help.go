@@ -0,0 +1,58 @@
+package filterer
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// the keys in the hint line
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Back, k.Focus, k.Unfocus, k.Favorite, k.Help, k.Quit}
+}
+
+// every key, a column for each kind of action
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Select, k.Back, k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Copy, k.Exec, k.Print, k.Edit, k.Favorite, k.Help, k.Quit},
+		{k.ToggleMode, k.Scope, k.Tags, k.Focus, k.Unfocus},
+		k.panelKeys(),
+	}
+}
+
+// the keys while the tag panel is open
+func (k KeyMap) panelKeys() []key.Binding {
+	return []key.Binding{k.TagLeft, k.TagRight, k.TagCheck, k.TagAll, k.TagClose}
+}
+
+// the help with the styles of the chooser
+func (m model) newHelp() help.Model {
+	h := help.New()
+	h.Styles.ShortKey = m.indicatorStyle
+	h.Styles.FullKey = m.indicatorStyle
+	h.Styles.ShortDesc = m.textStyle
+	h.Styles.FullDesc = m.textStyle
+	h.Styles.ShortSeparator = m.headerStyle
+	h.Styles.FullSeparator = m.headerStyle
+	return h
+}
+
+// the keys of the tag panel or the most used ones
+func (m model) renderHint() string {
+	if m.tagPanel {
+		return m.help.ShortHelpView(m.keys.panelKeys())
+	}
+	// only the way in or out of the filter that can be taken now
+	keys := m.keys
+	if m.textinput.Focused() {
+		keys.Focus.SetEnabled(false)
+	} else {
+		keys.Unfocus.SetEnabled(false)
+	}
+	return m.help.ShortHelpView(keys.ShortHelp())
+}
+
+// every key with what it does, in place of the options
+func (m model) renderHelp() string {
+	return m.headerStyle.Render("KEYS:") + "\n" + m.help.FullHelpView(m.keys.FullHelp())
+}
@@ -0,0 +1,309 @@
+package filterer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sebastianxyzsss/tardigrade/action"
+	"github.com/sebastianxyzsss/tardigrade/globals"
+)
+
+// the actions keys can be bound to, as they are named in the keys settings
+const (
+	ActionSelect     = "select"
+	ActionBack       = "back"
+	ActionUp         = "up"
+	ActionDown       = "down"
+	ActionPageUp     = "page-up"
+	ActionPageDown   = "page-down"
+	ActionCopy       = "copy"
+	ActionExec       = "exec"
+	ActionPrint      = "print"
+	ActionEdit       = "edit"
+	ActionFavorite   = "favorite"
+	ActionToggleMode = "toggle-mode"
+	ActionScope      = "scope"
+	ActionTags       = "tags"
+	ActionHelp       = "help"
+	ActionQuit       = "quit"
+	ActionFocus      = "focus"
+	ActionUnfocus    = "unfocus"
+	ActionTagLeft    = "tag-left"
+	ActionTagRight   = "tag-right"
+	ActionTagCheck   = "tag-check"
+	ActionTagAll     = "tag-all"
+	ActionTagClose   = "tag-close"
+)
+
+// ChosenMode is the mode each key chooses a command with, enter uses the one
+// of the launch
+func ChosenMode(chosenAction string) string {
+	switch chosenAction {
+	case ActionCopy:
+		return action.ModeCopy
+	case ActionExec:
+		return action.ModePrint
+	case ActionPrint:
+		return action.ModeComment
+	}
+	return globals.RunMode
+}
+
+// the key presets the keys settings start from
+const (
+	KeysDefault = "default"
+	KeysVim     = "vim"
+	KeysEmacs   = "emacs"
+)
+
+// every action in the order they are listed, with what it does, the ones of
+// the tag panel only act while it is open so they can share keys with the rest
+var actions = []struct {
+	name  string
+	help  string
+	panel bool
+}{
+	{ActionSelect, "open / choose", false},
+	{ActionBack, "back", false},
+	{ActionUp, "up", false},
+	{ActionDown, "down", false},
+	{ActionPageUp, "page up", false},
+	{ActionPageDown, "page down", false},
+	{ActionCopy, "copy", false},
+	{ActionExec, "run", false},
+	{ActionPrint, "print only", false},
+	{ActionEdit, "edit", false},
+	{ActionFavorite, "star", false},
+	{ActionToggleMode, "groups/flat", false},
+	{ActionScope, "search in", false},
+	{ActionTags, "tags", false},
+	{ActionHelp, "help", false},
+	{ActionQuit, "quit", false},
+	{ActionFocus, "search", false},
+	{ActionUnfocus, "stop searching", false},
+	{ActionTagLeft, "tags: previous", true},
+	{ActionTagRight, "tags: next", true},
+	{ActionTagCheck, "check", true},
+	{ActionTagAll, "any/all", true},
+	{ActionTagClose, "close", true},
+}
+
+// the keys the filter is edited with, like ctrl+a, ctrl+e or ctrl+w, are
+// left to it, the actions on the option use alt
+var defaultKeys = map[string][]string{
+	ActionSelect:     {"enter", "right"},
+	ActionBack:       {"left"},
+	ActionUp:         {"up", "ctrl+p", "ctrl+k"},
+	ActionDown:       {"down", "ctrl+n", "ctrl+j"},
+	ActionPageUp:     {"pgup"},
+	ActionPageDown:   {"pgdown"},
+	ActionCopy:       {"alt+c"},
+	ActionExec:       {"alt+x"},
+	ActionPrint:      {"alt+p"},
+	ActionEdit:       {"alt+e"},
+	ActionFavorite:   {"ctrl+o"},
+	ActionToggleMode: {"ctrl+t"},
+	ActionScope:      {"ctrl+s"},
+	ActionTags:       {"ctrl+g"},
+	ActionHelp:       {"?"},
+	ActionQuit:       {"esc", "ctrl+c", "ctrl+z"},
+	ActionTagLeft:    {"left", "shift+tab"},
+	ActionTagRight:   {"right"},
+	ActionTagCheck:   {"space", "enter"},
+	ActionTagAll:     {"tab"},
+	ActionTagClose:   {"esc"},
+}
+
+// the presets only list the actions they bind differently from the default
+var presets = map[string]map[string][]string{
+	KeysDefault: {},
+	// the letters work while the filter is not focused, / focuses it and esc
+	// leaves it
+	KeysVim: {
+		ActionSelect:     {"enter", "right", "l"},
+		ActionBack:       {"left", "h"},
+		ActionUp:         {"up", "ctrl+p", "k"},
+		ActionDown:       {"down", "ctrl+n", "j"},
+		ActionPageUp:     {"pgup", "ctrl+b"},
+		ActionPageDown:   {"pgdown", "ctrl+f"},
+		ActionCopy:       {"alt+c", "y"},
+		ActionExec:       {"alt+x", "x"},
+		ActionPrint:      {"alt+p", "p"},
+		ActionEdit:       {"alt+e", "e"},
+		ActionFavorite:   {"ctrl+o", "f"},
+		ActionToggleMode: {"ctrl+t", "t"},
+		ActionScope:      {"ctrl+s", "s"},
+		ActionTags:       {"ctrl+g", "#"},
+		ActionQuit:       {"ctrl+c", "ctrl+z", "q"},
+		ActionFocus:      {"/", "i"},
+		ActionUnfocus:    {"esc"},
+		ActionTagLeft:    {"left", "shift+tab", "h"},
+		ActionTagRight:   {"right", "l"},
+	},
+	// ctrl+k is left to edit the filter too
+	KeysEmacs: {
+		ActionUp:       {"up", "ctrl+p"},
+		ActionDown:     {"down", "ctrl+n"},
+		ActionPageUp:   {"pgup", "alt+v"},
+		ActionPageDown: {"pgdown", "ctrl+v"},
+		ActionCopy:     {"alt+w"},
+		ActionTags:     {"alt+g"},
+		ActionQuit:     {"ctrl+g", "esc", "ctrl+c", "ctrl+z"},
+	},
+}
+
+// the keys of every action
+type KeyMap struct {
+	Select     key.Binding
+	Back       key.Binding
+	Up         key.Binding
+	Down       key.Binding
+	PageUp     key.Binding
+	PageDown   key.Binding
+	Copy       key.Binding
+	Exec       key.Binding
+	Print      key.Binding
+	Edit       key.Binding
+	Favorite   key.Binding
+	ToggleMode key.Binding
+	Scope      key.Binding
+	Tags       key.Binding
+	Help       key.Binding
+	Quit       key.Binding
+	Focus      key.Binding
+	Unfocus    key.Binding
+	TagLeft    key.Binding
+	TagRight   key.Binding
+	TagCheck   key.Binding
+	TagAll     key.Binding
+	TagClose   key.Binding
+}
+
+func IsValidKeyPreset(preset string) bool {
+	_, ok := presets[preset]
+	return ok
+}
+
+// the names of the keys as bubbletea writes them, like ctrl+a or pgup
+var keyNames = func() map[string]bool {
+	names := map[string]bool{"space": true}
+	for k := tea.KeyType(-100); k <= tea.KeyBackspace; k++ {
+		if name := k.String(); name != "" && k != tea.KeyRunes {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+func isValidKey(name string) bool {
+	if keyNames[name] || utf8.RuneCountInString(name) == 1 {
+		return true
+	}
+	rest := strings.TrimPrefix(name, "alt+")
+	return rest != name && isValidKey(rest)
+}
+
+// the keys of the preset with the ones of the settings in their place, it
+// fails on unknown actions and keys, and on keys bound to two actions
+func NewKeyMap(preset string, custom map[string][]string) (KeyMap, error) {
+	if preset == "" {
+		preset = KeysDefault
+	}
+	if !IsValidKeyPreset(preset) {
+		return KeyMap{}, fmt.Errorf("unknown key preset %s, use default, vim or emacs", preset)
+	}
+
+	bound := make(map[string][]string)
+	for _, a := range actions {
+		bound[a.name] = defaultKeys[a.name]
+		if keys, ok := presets[preset][a.name]; ok {
+			bound[a.name] = keys
+		}
+	}
+	for action, keys := range custom {
+		if _, ok := bound[action]; !ok {
+			return KeyMap{}, fmt.Errorf("unknown action %s in the keys", action)
+		}
+		bound[action] = keys
+	}
+
+	errs := make([]string, 0)
+	owners := make(map[string]string)
+	bindings := make(map[string]key.Binding)
+	for _, a := range actions {
+		keys := make([]string, 0, len(bound[a.name]))
+		for _, k := range bound[a.name] {
+			if !isValidKey(k) {
+				errs = append(errs, fmt.Sprintf("unknown key %q for %s", k, a.name))
+				continue
+			}
+			owned := k
+			if a.panel {
+				owned = "panel:" + k
+			}
+			if owner, ok := owners[owned]; ok {
+				errs = append(errs, fmt.Sprintf("key %q is bound to %s and %s", k, owner, a.name))
+				continue
+			}
+			owners[owned] = a.name
+			if k == "space" {
+				k = " "
+			}
+			keys = append(keys, k)
+		}
+		binding := key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(bound[a.name], ","), a.help))
+		if len(keys) == 0 {
+			binding.SetEnabled(false)
+		}
+		bindings[a.name] = binding
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return KeyMap{}, errors.New(strings.Join(errs, ", "))
+	}
+
+	return KeyMap{
+		Select:     bindings[ActionSelect],
+		Back:       bindings[ActionBack],
+		Up:         bindings[ActionUp],
+		Down:       bindings[ActionDown],
+		PageUp:     bindings[ActionPageUp],
+		PageDown:   bindings[ActionPageDown],
+		Copy:       bindings[ActionCopy],
+		Exec:       bindings[ActionExec],
+		Print:      bindings[ActionPrint],
+		Edit:       bindings[ActionEdit],
+		Favorite:   bindings[ActionFavorite],
+		ToggleMode: bindings[ActionToggleMode],
+		Scope:      bindings[ActionScope],
+		Tags:       bindings[ActionTags],
+		Help:       bindings[ActionHelp],
+		Quit:       bindings[ActionQuit],
+		Focus:      bindings[ActionFocus],
+		Unfocus:    bindings[ActionUnfocus],
+		TagLeft:    bindings[ActionTagLeft],
+		TagRight:   bindings[ActionTagRight],
+		TagCheck:   bindings[ActionTagCheck],
+		TagAll:     bindings[ActionTagAll],
+		TagClose:   bindings[ActionTagClose],
+	}, nil
+}
+
+// if the key is pressed for one of the bindings: keys that type a letter or a
+// digit only act while the filter is not focused, other characters like ? also
+// while nothing was typed
+func (m model) pressed(msg tea.KeyMsg, bindings ...key.Binding) bool {
+	if msg.Type == tea.KeyRunes && !msg.Alt && m.textinput.Focused() {
+		r, _ := utf8.DecodeRuneInString(msg.String())
+		if m.textinput.Value() != "" || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return key.Matches(msg, bindings...)
+}
@@ -0,0 +1,68 @@
+package filterer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset string
+		custom map[string][]string
+		err    string
+	}{
+		{"default", "", nil, ""},
+		{"vim", KeysVim, nil, ""},
+		{"emacs", KeysEmacs, nil, ""},
+		{"unknown preset", "nano", nil, "unknown key preset"},
+		{"custom", "", map[string][]string{ActionCopy: {"ctrl+y", "c", "space"}}, ""},
+		{"unknown action", "", map[string][]string{"launch": {"x"}}, "unknown action launch"},
+		{"unknown key", "", map[string][]string{ActionCopy: {"hyper+c"}}, `unknown key "hyper+c"`},
+		{"key of two actions", "", map[string][]string{ActionCopy: {"alt+x"}}, `key "alt+x" is bound to copy and exec`},
+		// the tag panel keys only act while it is open
+		{"panel shares keys", "", map[string][]string{ActionTagCheck: {"ctrl+o"}}, ""},
+		{"key of two panel actions", "", map[string][]string{ActionTagAll: {"esc"}}, `key "esc" is bound to tag-all and tag-close`},
+	}
+
+	for _, test := range tests {
+		_, err := NewKeyMap(test.preset, test.custom)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestNewKeyMapBindings(t *testing.T) {
+	keys, err := NewKeyMap(KeysVim, map[string][]string{ActionCopy: {"space"}, ActionEdit: {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		binding key.Binding
+		keys    []string
+		enabled bool
+	}{
+		{"preset", keys.Quit, []string{"ctrl+c", "ctrl+z", "q"}, true},
+		{"default under a preset", keys.Help, []string{"?"}, true},
+		{"space", keys.Copy, []string{" "}, true},
+		{"no keys", keys.Edit, []string{}, false},
+		{"panel preset", keys.TagLeft, []string{"left", "shift+tab", "h"}, true},
+	}
+
+	for _, test := range tests {
+		if strings.Join(test.binding.Keys(), ",") != strings.Join(test.keys, ",") {
+			t.Errorf("%s: keys %q, want %q", test.name, test.binding.Keys(), test.keys)
+		}
+		if test.binding.Enabled() != test.enabled {
+			t.Errorf("%s: enabled %v, want %v", test.name, test.binding.Enabled(), test.enabled)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package filterer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+// where the preview of the highlighted option is shown
+const (
+	PreviewOff    = "off"
+	PreviewSide   = "side"
+	PreviewBottom = "bottom"
+)
+
+// lines the bottom preview can take
+const previewBottomHeight = 8
+
+// when an element was last chosen and how many times
+type UsageFunc func(element *parser.Element) (lastUsed time.Time, count int)
+
+func IsValidPreview(preview string) bool {
+	return preview == PreviewOff || preview == PreviewSide || preview == PreviewBottom
+}
+
+func (m model) previewWidth() int {
+	if m.width <= 0 {
+		return 40
+	}
+	if m.preview == PreviewSide {
+		return max(30, m.width*2/5)
+	}
+	return m.width
+}
+
+// the highlighted option in full, with what is known about it
+func (m model) renderPreview(element *parser.Element, width int, height int) string {
+	if element == nil {
+		return ""
+	}
+
+	wrap := lipgloss.NewStyle().Width(width)
+	lines := make([]string, 0)
+
+	if element.IsCommand {
+		lines = append(lines, m.headerStyle.Render("COMMAND"), wrap.Render(parser.CommandString(element)))
+	} else {
+		lines = append(lines, m.headerStyle.Render("GROUP ")+element.Content)
+		for _, child := range element.ChildrenSorted {
+			if !elementPassesPostCriteria(child) {
+				continue
+			}
+			prefix := "  "
+			if !child.IsCommand {
+				prefix = m.indicatorStyle.Render("▸ ")
+			}
+			lines = append(lines, prefix+parser.TruncateString(child.Content, width-4))
+		}
+	}
+
+	if element.Description != "" {
+		lines = append(lines, m.headerStyle.Render("DESC"), wrap.Render(m.renderHighlighted(element.Description, m.highlights[element].description)))
+	}
+	if len(element.Tags) > 0 {
+		lines = append(lines, m.headerStyle.Render("TAGS ")+wrap.Render(m.renderTags(element, ", ")))
+	}
+	if element.Source != "" {
+		lines = append(lines, m.headerStyle.Render("FROM ")+element.Source)
+	}
+	if element.IsCommand && m.usage != nil {
+		lines = append(lines, m.headerStyle.Render("USED ")+describeUsage(m.usage(element)))
+	}
+
+	return lipgloss.NewStyle().Width(width).MaxWidth(width).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
+
+func describeUsage(lastUsed time.Time, count int) string {
+	if count == 0 {
+		return "never"
+	}
+	times := strconv.Itoa(count) + " times"
+	if count == 1 {
+		times = "once"
+	}
+	if lastUsed.IsZero() {
+		return times
+	}
+	return times + ", last " + lastUsed.Local().Format("2006-01-02 15:04")
+}
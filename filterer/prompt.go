@@ -0,0 +1,79 @@
+package filterer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sebastianxyzsss/tardigrade/utils"
+)
+
+type promptModel struct {
+	textinput   textinput.Model
+	label       string
+	headerStyle lipgloss.Style
+	aborted     bool
+	quitting    bool
+}
+
+func (m promptModel) Init() tea.Cmd { return textinput.Blink }
+
+func (m promptModel) View() string {
+	if m.quitting {
+		return ""
+	}
+	return m.headerStyle.Render(m.label) + "\n" + m.textinput.View()
+}
+
+func (m promptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.aborted = true
+			m.quitting = true
+			return m, tea.Quit
+		case "enter":
+			m.quitting = true
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.textinput, cmd = m.textinput.Update(msg)
+	return m, cmd
+}
+
+// PromptPlaceholders asks for a value for every secret placeholder, typed in a
+// masked input. The returned map goes from token to value.
+func (o Options) PromptPlaceholders(placeholders []utils.Placeholder) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for _, placeholder := range placeholders {
+		i := textinput.New()
+		i.Focus()
+		i.Prompt = o.Prompt
+		i.PromptStyle = o.PromptStyle.ToLipgloss()
+		i.Placeholder = placeholder.Name
+		i.EchoMode = textinput.EchoPassword
+		i.EchoCharacter = '•'
+
+		p := tea.NewProgram(promptModel{
+			textinput:   i,
+			label:       "secret value for " + placeholder.Name + " (not saved)",
+			headerStyle: o.HeaderStyle.ToLipgloss(),
+		}, tea.WithOutput(os.Stderr))
+
+		tm, err := p.Run()
+		if err != nil {
+			return nil, fmt.Errorf("unable to run prompt: %w", err)
+		}
+		m := tm.(promptModel)
+		if m.aborted {
+			return nil, ErrAborted
+		}
+		values[placeholder.Token] = m.textinput.Value()
+	}
+
+	return values, nil
+}
@@ -0,0 +1,56 @@
+package filterer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sahilm/fuzzy"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+// how the matches are sorted
+const (
+	RankDeclared = "declared" // commands as in the content files, groups by name
+	RankFuzzy    = "fuzzy"    // by fuzzy score only
+	RankBlended  = "blended"  // by fuzzy score and frecency
+)
+
+// scores how often and how recently an element was chosen
+type FrecencyFunc func(element *parser.Element) float64
+
+// how much frecency counts against the fuzzy score in blended ranking
+const frecencyWeight = 10.0
+
+func IsValidRanking(ranking string) bool {
+	return ranking == RankDeclared || ranking == RankFuzzy || ranking == RankBlended
+}
+
+// sorts matches of the children of element, hasQuery tells if the matches come
+// from a search or are all the children
+func rankMatches(matches []fuzzy.Match, element *parser.Element, ranking string, hasQuery bool, frecency FrecencyFunc) []fuzzy.Match {
+	switch ranking {
+	case RankDeclared:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Index < matches[j].Index
+		})
+	case RankBlended:
+		// without a query the options keep their order, and the history and
+		// the virtual groups keep the order they were made in
+		if frecency == nil || !hasQuery || element.Virtual || parser.IsHistoryGroup(element) {
+			break
+		}
+		scores := make(map[int]float64, len(matches))
+		for _, match := range matches {
+			scores[match.Index] = float64(match.Score) + frecencyWeight*math.Log1p(frecency(element.ChildrenSorted[match.Index]))
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return scores[matches[i].Index] > scores[matches[j].Index]
+		})
+	}
+
+	// the virtual groups, like the favorites, stay on top
+	sort.SliceStable(matches, func(i, j int) bool {
+		return element.ChildrenSorted[matches[i].Index].Virtual && !element.ChildrenSorted[matches[j].Index].Virtual
+	})
+	return matches
+}
@@ -0,0 +1,75 @@
+package filterer
+
+import "github.com/sebastianxyzsss/tardigrade/parser"
+
+// what the typed filter is compared with
+const (
+	ScopeContent     = "content"
+	ScopeDescription = "description"
+	ScopeTags        = "tags"
+	ScopeEverything  = "everything"
+)
+
+// the order the scopes are switched in
+var scopes = []string{ScopeEverything, ScopeContent, ScopeDescription, ScopeTags}
+
+func IsValidScope(scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func nextScope(scope string) string {
+	for i, s := range scopes {
+		if s == scope {
+			return scopes[(i+1)%len(scopes)]
+		}
+	}
+	return ScopeEverything
+}
+
+// the query field plain words look in for each scope
+func scopeField(scope string) string {
+	switch scope {
+	case ScopeDescription:
+		return parser.FieldDesc
+	case ScopeTags:
+		return parser.FieldTag
+	case ScopeEverything:
+		return parser.FieldAll
+	}
+	return parser.FieldCmd
+}
+
+// switches between browsing the groups and all the commands in one list,
+// what was typed is kept
+func (m *model) toggleFlat() {
+	m.flat = !m.flat
+	m.stack = make([]frame, 0)
+	if m.flat {
+		m.element = parser.Flatten(m.root)
+	} else {
+		m.element = m.root
+	}
+	m.choices = getChoices(m.element)
+	m.refresh()
+}
+
+func (m *model) switchScope() {
+	m.scope = nextScope(m.scope)
+	m.refresh()
+}
+
+// matches again from the top after the options or the scope changed
+func (m *model) refresh() {
+	m.cursor = 0
+	m.viewport.YOffset = 0
+	m.updateMatches()
+
+	if m.reverse {
+		m.viewport.YOffset = clamp(0, len(m.matches), len(m.matches)-m.viewport.Height)
+	}
+}
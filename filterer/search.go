@@ -0,0 +1,224 @@
+package filterer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+// how much a match in each part of an element counts, the command the most
+const (
+	contentWeight     = 3
+	tagsWeight        = 2
+	descriptionWeight = 1
+)
+
+// a part matching counts more than how well it matches
+const fieldScore = 100
+
+// what matched in the description and the tags of an element, the content
+// ones are kept in the match itself
+type fieldMatches struct {
+	description []int
+	tags        map[int][]int // by position of the tag
+}
+
+func (f fieldMatches) isEmpty() bool {
+	return len(f.description) == 0 && len(f.tags) == 0
+}
+
+// matches the query with the elements in the current scope, the matches show
+// the content of the elements and are sorted by how well they match
+func (m *model) findMatches(query string, elements []*parser.Element) []fuzzy.Match {
+	m.highlights = make(map[*parser.Element]fieldMatches)
+
+	parsed := parser.ParseQuery(query, scopeField(m.scope))
+	if !parsed.Plain() {
+		return m.queryMatches(parsed, elements)
+	}
+
+	inContent := m.scope == ScopeContent || m.scope == ScopeEverything
+	inDescription := m.scope == ScopeDescription || m.scope == ScopeEverything
+	inTags := m.scope == ScopeTags || m.scope == ScopeEverything
+
+	matches := make([]fuzzy.Match, 0)
+	for i, element := range elements {
+		match := fuzzy.Match{Str: element.Content, Index: i, MatchedIndexes: []int{}}
+		found := false
+		highlight := fieldMatches{tags: make(map[int][]int)}
+
+		if inContent {
+			if score, indexes, ok := m.matchText(query, element.Content); ok {
+				match.Score += contentWeight * score
+				match.MatchedIndexes = indexes
+				found = true
+			}
+		}
+		if inDescription {
+			// fuzzy matching across all the words of the descriptions finds
+			// nearly everything, they have to have what was typed in them
+			matchDescription := m.matchText
+			if m.scope == ScopeEverything {
+				matchDescription = m.matchSubstring
+			}
+			if score, indexes, ok := matchDescription(query, element.Description); ok {
+				match.Score += descriptionWeight * score
+				highlight.description = indexes
+				found = true
+			}
+		}
+		if inTags {
+			for j, tag := range element.Tags {
+				if score, indexes, ok := matchTag(query, tag); ok {
+					match.Score += tagsWeight * score
+					highlight.tags[j] = indexes
+					found = true
+				}
+			}
+		}
+
+		if found {
+			matches = append(matches, match)
+			if !highlight.isEmpty() {
+				m.highlights[element] = highlight
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// matches one text with fuzzy or exact matching, the score is always positive
+func (m model) matchText(query string, text string) (int, []int, bool) {
+	if text == "" {
+		return 0, nil, false
+	}
+	var found []fuzzy.Match
+	if m.fuzzy {
+		found = fuzzy.Find(query, []string{text})
+	} else {
+		found = exactMatches(query, []string{text})
+	}
+	if len(found) == 0 {
+		return 0, nil, false
+	}
+	return max(1, fieldScore+found[0].Score), found[0].MatchedIndexes, true
+}
+
+// matches a tag as the launch filters and the queries do, see
+// parser.TagContains, a tag found by an alias is highlighted whole
+func matchTag(query string, tag string) (int, []int, bool) {
+	if !parser.TagContains(tag, query) {
+		return 0, nil, false
+	}
+	if found := exactMatches(query, []string{tag}); len(found) > 0 {
+		return fieldScore, found[0].MatchedIndexes, true
+	}
+	indexes := make([]int, 0, len(tag))
+	for i := range tag {
+		indexes = append(indexes, i)
+	}
+	return fieldScore, indexes, true
+}
+
+// matches one text only where it has the query in it, ignoring case
+func (m model) matchSubstring(query string, text string) (int, []int, bool) {
+	found := exactMatches(query, []string{text})
+	if text == "" || len(found) == 0 {
+		return 0, nil, false
+	}
+	return fieldScore, found[0].MatchedIndexes, true
+}
+
+// the elements the query matches, the words looked for are highlighted
+func (m *model) queryMatches(query parser.Query, elements []*parser.Element) []fuzzy.Match {
+	matches := make([]fuzzy.Match, 0)
+	for i, element := range elements {
+		if !query.Matches(element) {
+			continue
+		}
+		matches = append(matches, fuzzy.Match{
+			Str:            element.Content,
+			Index:          i,
+			MatchedIndexes: queryHighlights(query, element.Content, parser.FieldCmd),
+		})
+
+		highlight := fieldMatches{
+			description: queryHighlights(query, element.Description, parser.FieldDesc),
+			tags:        make(map[int][]int),
+		}
+		for j, tag := range element.Tags {
+			if indexes := queryHighlights(query, tag, parser.FieldTag); len(indexes) > 0 {
+				highlight.tags[j] = indexes
+			}
+		}
+		if !highlight.isEmpty() {
+			m.highlights[element] = highlight
+		}
+	}
+	return matches
+}
+
+// the indexes of text where the terms looking in field, or in all, are found
+func queryHighlights(query parser.Query, text string, field string) []int {
+	lowered := strings.ToLower(text)
+	highlighted := make(map[int]bool)
+	for _, group := range query.Groups {
+		for _, term := range group {
+			if term.Negated || (term.Field != field && term.Field != parser.FieldAll) {
+				continue
+			}
+			value := strings.ToLower(strings.TrimSuffix(term.Value, "*"))
+			index := strings.Index(lowered, value)
+			if index < 0 {
+				continue
+			}
+			for s := range value {
+				highlighted[index+s] = true
+			}
+		}
+	}
+
+	indexes := make([]int, 0, len(highlighted))
+	for index := range highlighted {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// the text with the matched indexes in the match style
+func (m model) renderHighlighted(text string, indexes []int) string {
+	if len(indexes) == 0 {
+		return text
+	}
+	var s, buf strings.Builder
+	mi := 0
+	for ci, c := range text {
+		if mi < len(indexes) && ci == indexes[mi] {
+			s.WriteString(m.textStyle.Render(buf.String()))
+			buf.Reset()
+			s.WriteString(m.matchStyle.Render(string(c)))
+			mi++
+		} else {
+			buf.WriteRune(c)
+		}
+	}
+	s.WriteString(m.textStyle.Render(buf.String()))
+	return s.String()
+}
+
+// the tags of the element joined, with what matched highlighted
+func (m model) renderTags(element *parser.Element, separator string) string {
+	highlight := m.highlights[element]
+	tags := make([]string, len(element.Tags))
+	for i, tag := range element.Tags {
+		tags[i] = m.renderHighlighted(tag, highlight.tags[i])
+	}
+	return strings.Join(tags, separator)
+}
@@ -0,0 +1,163 @@
+package filterer
+
+import (
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+// the whole hierarchy in one list, groups open and close in place
+const LayoutTree = "tree"
+
+// one visible line of the tree layout
+type treeRow struct {
+	element *parser.Element
+	depth   int
+}
+
+// the children that can be shown, same as the options of a group
+func treeChildren(group *parser.Element) []*parser.Element {
+	getChoices(group)
+	return group.ChildrenSorted
+}
+
+func collectTree(group *parser.Element, all *[]*parser.Element) {
+	for _, child := range treeChildren(group) {
+		*all = append(*all, child)
+		if !child.IsCommand {
+			collectTree(child, all)
+		}
+	}
+}
+
+// rebuilds the visible rows, with a filter every element that matches is shown
+// together with the groups it is in
+func (m *model) updateTreeMatches() {
+	m.rows = make([]treeRow, 0)
+	m.matches = make([]fuzzy.Match, 0)
+
+	all := make([]*parser.Element, 0)
+	collectTree(m.element, &all)
+	m.treeSize = len(all)
+
+	query := m.textinput.Value()
+	var found []fuzzy.Match
+	if query == "" {
+		m.highlights = nil
+		found = matchAll(make([]string, len(all)))
+	} else {
+		found = m.findMatches(query, all)
+	}
+	found = m.narrowByTags(found, all)
+
+	if !m.searching() {
+		m.appendTreeRows(m.element, 0, nil, nil)
+		return
+	}
+
+	matched := make(map[*parser.Element][]int)
+	visible := make(map[*parser.Element]bool)
+	for _, match := range found {
+		element := all[match.Index]
+		matched[element] = match.MatchedIndexes
+		for e := element; e != nil && e != m.element; e = e.Parent {
+			visible[e] = true
+		}
+	}
+
+	m.appendTreeRows(m.element, 0, matched, visible)
+
+	// the groups around are only context, start on the first real match
+	for i, row := range m.rows {
+		if _, ok := matched[row.element]; ok {
+			m.cursor = i
+			break
+		}
+	}
+	if m.cursor < m.viewport.YOffset {
+		m.viewport.YOffset = m.cursor
+	} else if m.cursor >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.YOffset = m.cursor - m.viewport.Height + 1
+	}
+}
+
+func (m *model) appendTreeRows(group *parser.Element, depth int, matched map[*parser.Element][]int, visible map[*parser.Element]bool) {
+	for _, child := range treeChildren(group) {
+		if visible != nil && !visible[child] {
+			continue
+		}
+		open := !child.IsCommand && (visible != nil || m.expanded[child])
+
+		marker := "  "
+		if !child.IsCommand {
+			marker = "▸ "
+			if open {
+				marker = "▾ "
+			}
+		}
+		prefix := strings.Repeat("  ", depth) + marker
+
+		matchedIndexes := make([]int, 0)
+		for _, index := range matched[child] {
+			matchedIndexes = append(matchedIndexes, index+len(prefix))
+		}
+
+		m.rows = append(m.rows, treeRow{element: child, depth: depth})
+		m.matches = append(m.matches, fuzzy.Match{
+			Str:            prefix + child.Content,
+			Index:          len(m.rows) - 1,
+			MatchedIndexes: matchedIndexes,
+		})
+
+		if open {
+			m.appendTreeRows(child, depth+1, matched, visible)
+		}
+	}
+}
+
+// with a filter or selected tags the tree shows only what matches
+func (m model) searching() bool {
+	return m.textinput.Value() != "" || m.narrowing()
+}
+
+// closes an open group, or goes to the group the option is in
+func (m *model) treeLeft() {
+	element := m.highlighted()
+	if element == nil {
+		return
+	}
+	if m.expanded[element] && !m.searching() {
+		delete(m.expanded, element)
+		m.updateTreeMatches()
+		return
+	}
+	depth := m.rows[m.matches[m.cursor].Index].depth
+	for m.cursor > 0 {
+		m.CursorUp()
+		if m.rows[m.matches[m.cursor].Index].depth < depth {
+			return
+		}
+	}
+}
+
+// opens and closes a group, it returns true when the option is a command
+func (m *model) treeEnter() bool {
+	element := m.highlighted()
+	if element == nil {
+		return false
+	}
+	if element.IsCommand {
+		return true
+	}
+	if m.searching() {
+		return false
+	}
+	if m.expanded[element] {
+		delete(m.expanded, element)
+	} else {
+		m.expanded[element] = true
+	}
+	m.updateTreeMatches()
+	return false
+}
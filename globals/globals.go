@@ -1,6 +1,9 @@
 package globals
 
-import "github.com/sebastianxyzsss/tardigrade/action"
+import (
+	"github.com/charmbracelet/gum/style"
+	"github.com/sebastianxyzsss/tardigrade/action"
+)
 
 type FilterType int
 
@@ -9,26 +12,51 @@ const (
 	FilterFiles
 	FilterTags
 	FilterAnything
+	FilterQuery
 )
 
 var FilterAction FilterType = FilterNone
 
 var FilterStrings []string = make([]string, 0)
 
-var RunMode string = "print-command"
-
-var RunAction action.Action = action.Printer{}
+// the mode commands are chosen with by default, with enter
+var RunMode string = action.ModePrint
 
 type Settings struct {
-	Height           int    `json:"height"`
-	HistorySize      int    `json:"historysize"`
-	IndicatorStyle   string `json:"indicatorstyle"`
-	FooterKeyMaxSize int    `json:"footerkeymaxsize"`
-	LogLevel         string `json:"loglevel"`
+	Height           int                 `json:"height"`
+	HistorySize      int                 `json:"historysize"`
+	HistoryStoreSize int                 `json:"historystoresize"`
+	IndicatorStyle   string              `json:"indicatorstyle"`
+	FooterKeyMaxSize int                 `json:"footerkeymaxsize"`
+	LogLevel         string              `json:"loglevel"`
+	Redactions       []string            `json:"redactions"`
+	Ranking          string              `json:"ranking"`
+	BackupCount      int                 `json:"backupcount"`
+	GitVersioning    bool                `json:"gitversioning"`
+	GitHistory       bool                `json:"githistory"`
+	Preview          string              `json:"preview"`
+	Layout           string              `json:"layout"`
+	TagAliases       map[string][]string `json:"tagaliases"`
+	Smart            map[string]string   `json:"smart"`
+	KeyPreset        string              `json:"keypreset"`
+	Keys             map[string][]string `json:"keys"`
+	ThemePreset      string              `json:"themepreset"`
+	Theme            ThemeStyles         `json:"theme"`
 }
 
+// the styles of the chooser by part, like header or match
+type ThemeStyles map[string]style.Styles
+
 var ChildKeyMaxSize int = 8
 
 var FlatParse bool = false
 
 var HistorySize int = 10
+
+var HistoryStoreSize int = 1000
+
+var BackupCount int = 10
+
+var GitVersioning bool = false
+
+var GitHistory bool = false
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sebastianxyzsss/tardigrade/parser"
+	"github.com/sebastianxyzsss/tardigrade/redacter"
+)
+
+// frequency times recency of the commands in the history
+type Frecency struct {
+	byCommand map[string]float64
+	byPath    map[string]float64
+}
+
+// like zoxide, a recent use weighs more than an old one
+func recencyWeight(now time.Time, used time.Time) float64 {
+	if used.IsZero() {
+		return 0.25
+	}
+	age := now.Sub(used)
+	switch {
+	case age < time.Hour:
+		return 4
+	case age < 24*time.Hour:
+		return 2
+	case age < 7*24*time.Hour:
+		return 0.5
+	default:
+		return 0.25
+	}
+}
+
+func (h *History) Frecency(now time.Time) *Frecency {
+	f := &Frecency{
+		byCommand: make(map[string]float64),
+		byPath:    make(map[string]float64),
+	}
+	for _, entry := range h.Entries {
+		score := float64(entry.Count) * recencyWeight(now, entry.Time)
+		f.byCommand[entry.Command] += score
+		if entry.Path != "" {
+			f.byPath[entry.Path] += score
+		}
+	}
+	return f
+}
+
+// the history keeps commands redacted, so they are looked up redacted; only
+// the command counts, the copies flat mode makes of commands have other paths
+// and have to rank the same
+func (f *Frecency) commandScore(element *parser.Element) float64 {
+	return f.byCommand[redacter.Redact(parser.CommandString(element))]
+}
+
+// a command scores by itself, a group by all the commands chosen inside it
+func (f *Frecency) Score(element *parser.Element) float64 {
+	if f == nil || element == nil {
+		return 0
+	}
+	if element.IsCommand {
+		return f.commandScore(element)
+	}
+
+	groupPath := parser.ElementPath(element)
+	if groupPath == "" {
+		return 0
+	}
+	score := 0.0
+	for path, pathScore := range f.byPath {
+		if strings.HasPrefix(path, groupPath+parser.PathSeparator) {
+			score += pathScore
+		}
+	}
+	return score
+}
@@ -0,0 +1,56 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+func TestFrecencySameInFlatAndTree(t *testing.T) {
+	root := parser.NewElement("root", false, nil)
+	parser.RecurseMap(map[interface{}]interface{}{
+		"deploy": map[interface{}]interface{}{
+			"kubectl rollout restart <> ^ @comm": []interface{}{"api", "web"},
+		},
+		"tools": []interface{}{"git status"},
+	}, root)
+
+	now := time.Now()
+	h := &History{Entries: []Entry{
+		{Command: "kubectl rollout restart api", Path: "deploy/kubectl rollout restart <>/api", Time: now, Count: 3},
+		{Command: "git status", Path: "all/git status", Time: now.Add(-48 * time.Hour), Count: 2},
+		// the path of a command whose text has changed since it was chosen
+		{Command: "kubectl rollout restart web --now", Path: "deploy/kubectl rollout restart <>/web", Time: now, Count: 1},
+	}}
+	f := h.Frecency(now)
+
+	tree := make(map[string]float64)
+	parser.Walk(root, func(element *parser.Element) {
+		if element.IsCommand {
+			tree[parser.CommandString(element)] = f.Score(element)
+		}
+	})
+
+	flat := parser.Flatten(root)
+	if len(flat.ChildrenSorted) != 3 {
+		t.Fatalf("flat mode has %d commands, want 3", len(flat.ChildrenSorted))
+	}
+	for _, element := range flat.ChildrenSorted {
+		command := parser.CommandString(element)
+		score, ok := tree[command]
+		if !ok {
+			t.Fatalf("%q is not in the tree", command)
+		}
+		if got := f.Score(element); got != score {
+			t.Errorf("%q scores %v in flat mode and %v in the tree", command, got, score)
+		}
+	}
+
+	if tree["kubectl rollout restart api"] != 12 {
+		t.Errorf("kubectl rollout restart api scores %v, want 12", tree["kubectl rollout restart api"])
+	}
+	if tree["kubectl rollout restart web"] != 0 {
+		t.Errorf("kubectl rollout restart web scores %v, want 0", tree["kubectl rollout restart web"])
+	}
+}
@@ -0,0 +1,359 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sebastianxyzsss/tardigrade/action"
+	"github.com/sebastianxyzsss/tardigrade/globals"
+	"github.com/sebastianxyzsss/tardigrade/logger"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+	"github.com/sebastianxyzsss/tardigrade/reader"
+	"github.com/sebastianxyzsss/tardigrade/redacter"
+	"gopkg.in/yaml.v2"
+)
+
+var ll = logger.SetupLog()
+
+const currentVersion = 2
+
+// one command chosen with tardigrade
+type Entry struct {
+	Command  string    `yaml:"command"`
+	Path     string    `yaml:"path,omitempty"`
+	Time     time.Time `yaml:"time,omitempty"`
+	Dir      string    `yaml:"dir,omitempty"`
+	Mode     string    `yaml:"mode,omitempty"`
+	ExitCode *int      `yaml:"exitcode,omitempty"`
+	Duration float64   `yaml:"duration,omitempty"` // in seconds
+	Count    int       `yaml:"count"`
+}
+
+// the content of tardihistory.yml, entries are sorted from the most recent
+type History struct {
+	Version int     `yaml:"version"`
+	Entries []Entry `yaml:"entries"`
+}
+
+// the format used before the history had structured records
+type legacyHistory struct {
+	History []string `yaml:"history"`
+}
+
+func NewEntry(command string, element *parser.Element, mode string) Entry {
+	dir, err := os.Getwd()
+	if err != nil {
+		ll.Debug().Msg("unable to get working dir: " + err.Error())
+	}
+	return Entry{
+		Command: command,
+		Path:    redacter.Redact(parser.ElementPath(element)),
+		Time:    time.Now(),
+		Dir:     dir,
+		Mode:    mode,
+		Count:   1,
+	}
+}
+
+// reads the history file, converting it if it still has the old format
+func Load() (*History, error) {
+	userTardiHistory, err := reader.GetHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return parse(reader.GetFileAsString(*userTardiHistory))
+}
+
+func parse(historyContent *string) (*History, error) {
+	if historyContent == nil {
+		ll.Debug().Msg("history does not exist yet")
+		return &History{Version: currentVersion, Entries: []Entry{}}, nil
+	}
+
+	h := &History{}
+	err := yaml.Unmarshal([]byte(*historyContent), h)
+	if err != nil || h.Version == 0 {
+		ll.Debug().Msg("history has the old format, migrating")
+		return migrate(*historyContent)
+	}
+
+	return h, nil
+}
+
+func migrate(historyContent string) (*History, error) {
+	legacy := legacyHistory{}
+	err := yaml.Unmarshal([]byte(historyContent), &legacy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read history: %w", err)
+	}
+
+	h := &History{Version: currentVersion, Entries: []Entry{}}
+	for _, command := range legacy.History {
+		h.Entries = append(h.Entries, Entry{Command: command, Count: 1})
+	}
+	return h, nil
+}
+
+func (h *History) marshal() (string, error) {
+	h.Version = currentVersion
+	y, err := yaml.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	return string(y), nil
+}
+
+// re-reads the history under the file lock, applies change and saves it, so
+// commands saved meanwhile by another terminal are kept
+func update(change func(h *History) error) error {
+	userTardiHistory, err := reader.GetHistoryPath()
+	if err != nil {
+		return err
+	}
+	return reader.UpdateFile(*userTardiHistory, func(current *string) (string, error) {
+		h, err := parse(current)
+		if err != nil {
+			return "", err
+		}
+		err = change(h)
+		if err != nil {
+			return "", err
+		}
+		return h.marshal()
+	})
+}
+
+// puts the entry first, merging it with an earlier entry of the same command
+func (h *History) Add(entry Entry) {
+	entries := []Entry{entry}
+
+	for _, old := range h.Entries {
+		if old.Command != entry.Command {
+			entries = append(entries, old)
+			continue
+		}
+		entries[0].Count = old.Count + entry.Count
+		// chosen again from the history group, keep where it came from
+		if entry.Path == "" || parser.IsPathInGroup(entry.Path, parser.HistoryGroup) || parser.IsPathInGroup(entry.Path, parser.HistoryHereGroup) {
+			entries[0].Path = old.Path
+		}
+	}
+
+	if len(entries) > globals.HistoryStoreSize {
+		entries = entries[:globals.HistoryStoreSize]
+	}
+	h.Entries = entries
+}
+
+// the most recent commands, without repeats, at most size of them
+func (h *History) Commands(size int) []string {
+	commands := make([]string, 0)
+	for _, entry := range h.Entries {
+		if len(commands) >= size {
+			break
+		}
+		commands = append(commands, entry.Command)
+	}
+	return commands
+}
+
+// the history as content groups, so it can be shown in the menu
+func (h *History) AsMap(size int) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{})
+	commands := h.Commands(size)
+	if len(commands) == 0 {
+		return m
+	}
+	m[parser.HistoryGroup] = toList(commands)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		ll.Debug().Msg("unable to get working dir: " + err.Error())
+		return m
+	}
+	m[parser.HistoryHereGroup] = toList(h.CommandsHere(dir, size))
+	return m
+}
+
+func toList(commands []string) []interface{} {
+	list := make([]interface{}, len(commands))
+	for i, command := range commands {
+		list[i] = command
+	}
+	return list
+}
+
+// versions the history file after a change, when githistory is set
+func commitHistory(message string) error {
+	userTardiHistory, err := reader.GetHistoryPath()
+	if err != nil {
+		return err
+	}
+	return reader.CommitChange(message, *userTardiHistory)
+}
+
+// adds one chosen command to the history file
+func Record(entry Entry) error {
+	err := update(func(h *History) error {
+		h.Add(entry)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return commitHistory("history: chose " + entry.Command)
+}
+
+// sets how the last chosen command ended, as reported by the shell wrapper,
+// a command that already has a result, was never chosen since the history
+// got structured records, or was copied or only printed instead of run, is
+// left as it is
+func RecordResult(exitCode int, duration float64) error {
+	command := ""
+	err := update(func(h *History) error {
+		if len(h.Entries) == 0 || h.Entries[0].ExitCode != nil || h.Entries[0].Time.IsZero() || !wasRun(h.Entries[0]) {
+			ll.Debug().Msg("no pending command to record the result for")
+			return nil
+		}
+		h.Entries[0].ExitCode = &exitCode
+		h.Entries[0].Duration = duration
+		command = h.Entries[0].Command
+		return nil
+	})
+	if err != nil || command == "" {
+		return err
+	}
+	return commitHistory(fmt.Sprintf("history: %s exited with %d", command, exitCode))
+}
+
+// if the shell wrapper ran the command, entries saved before modes were
+// recorded were run
+func wasRun(entry Entry) bool {
+	return entry.Mode == "" || entry.Mode == action.ModePrint
+}
+
+// rewrites the history file applying the current redaction patterns
+func Scrub() error {
+	scrubbed := 0
+	err := update(func(h *History) error {
+		entries := make([]Entry, 0)
+		byCommand := make(map[string]int)
+		for _, entry := range h.Entries {
+			command := redacter.Redact(entry.Command)
+			path := redacter.Redact(entry.Path)
+			if command != entry.Command || path != entry.Path {
+				scrubbed++
+			}
+			entry.Command = command
+			entry.Path = path
+			// the redacted command may now be the same as a more recent one
+			if i, ok := byCommand[command]; ok {
+				entries[i].Count += entry.Count
+				continue
+			}
+			byCommand[command] = len(entries)
+			entries = append(entries, entry)
+		}
+		h.Entries = entries
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the backups of the history still have the secrets
+	userTardiHistory, err := reader.GetHistoryPath()
+	if err != nil {
+		return err
+	}
+	err = reader.RemoveBackups(*userTardiHistory)
+	if err != nil {
+		return err
+	}
+
+	ll.Info().Int("scrubbed", scrubbed).Msg("history rewritten")
+	if globals.GitVersioning && globals.GitHistory {
+		ll.Warn().Msg("githistory is set, older versions of the history are still in the git repository of " + reader.TardiContentDir)
+	}
+	return commitHistory(fmt.Sprintf("history: scrubbed %d entries", scrubbed))
+}
+
+// when a command was last chosen and how many times
+func (h *History) Usage(element *parser.Element) (time.Time, int) {
+	if h == nil || element == nil || !element.IsCommand {
+		return time.Time{}, 0
+	}
+	command := redacter.Redact(parser.CommandString(element))
+	path := parser.ElementPath(element)
+	for _, entry := range h.Entries {
+		if entry.Command == command || entry.Path == path {
+			return entry.Time, entry.Count
+		}
+	}
+	return time.Time{}, 0
+}
+
+// the elements of the most recent commands that are still in the tree, at
+// most size of them
+func (h *History) Recent(index parser.Index, size int) []*parser.Element {
+	elements := make([]*parser.Element, 0)
+	seen := make(map[*parser.Element]bool)
+	for _, entry := range h.Entries {
+		if len(elements) >= size {
+			break
+		}
+		element := index.Find(entry.Path, entry.Command)
+		if element == nil || seen[element] {
+			continue
+		}
+		seen[element] = true
+		elements = append(elements, element)
+	}
+	return elements
+}
+
+// the git repository root that contains dir, or dir itself when there is none
+func ProjectDir(dir string) string {
+	for current := dir; current != ""; {
+		if !reader.DoesFileNotExist(filepath.Join(current, ".git")) {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+	return dir
+}
+
+// the most recent commands chosen in the same directory or repository as dir,
+// or the global ones when there are none
+func (h *History) CommandsHere(dir string, size int) []string {
+	project := ProjectDir(dir)
+	projects := make(map[string]string)
+
+	commands := make([]string, 0)
+	for _, entry := range h.Entries {
+		if len(commands) >= size {
+			break
+		}
+		if entry.Dir == "" {
+			continue
+		}
+		if _, ok := projects[entry.Dir]; !ok {
+			projects[entry.Dir] = ProjectDir(entry.Dir)
+		}
+		if entry.Dir == dir || projects[entry.Dir] == project {
+			commands = append(commands, entry.Command)
+		}
+	}
+
+	if len(commands) == 0 {
+		ll.Debug().Msg("no history for " + dir + ", using the global one")
+		return h.Commands(size)
+	}
+	return commands
+}
@@ -0,0 +1,137 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sebastianxyzsss/tardigrade/reader"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string
+		want    []Entry
+	}{
+		{"missing", nil, []Entry{}},
+		{"legacy", strPtr("history:\n  - ls -la\n  - git status\n"), []Entry{
+			{Command: "ls -la", Count: 1},
+			{Command: "git status", Count: 1},
+		}},
+		{"legacy empty", strPtr("history: []\n"), []Entry{}},
+		{"current", strPtr("version: 2\nentries:\n  - command: ls\n    path: tools/ls\n    mode: print-command\n    count: 3\n"), []Entry{
+			{Command: "ls", Path: "tools/ls", Mode: "print-command", Count: 3},
+		}},
+	}
+
+	for _, test := range tests {
+		h, err := parse(test.content)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if h.Version != currentVersion {
+			t.Errorf("%s: version %d, want %d", test.name, h.Version, currentVersion)
+		}
+		if !reflect.DeepEqual(h.Entries, test.want) {
+			t.Errorf("%s: entries %+v, want %+v", test.name, h.Entries, test.want)
+		}
+	}
+
+	if _, err := parse(strPtr("history: {")); err == nil {
+		t.Error("parse of broken yaml did not fail")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	h := &History{Entries: []Entry{
+		{Command: "ls", Path: "tools/ls", Count: 2},
+		{Command: "pwd", Path: "tools/pwd", Count: 1},
+	}}
+
+	h.Add(Entry{Command: "pwd", Path: "history/pwd", Count: 1})
+	h.Add(Entry{Command: "make", Path: "build/make", Count: 1})
+
+	want := []Entry{
+		{Command: "make", Path: "build/make", Count: 1},
+		// chosen from the history group, the path it came from is kept
+		{Command: "pwd", Path: "tools/pwd", Count: 2},
+		{Command: "ls", Path: "tools/ls", Count: 2},
+	}
+	if !reflect.DeepEqual(h.Entries, want) {
+		t.Errorf("entries %+v, want %+v", h.Entries, want)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	historyPath := filepath.Join(home, reader.TardiHistory)
+
+	content := "version: 2\nentries:\n" +
+		"  - command: deploy --token=abc\n    count: 1\n" +
+		"  - command: deploy --token=def\n    count: 2\n" +
+		"  - command: ls\n    count: 1\n"
+	err := reader.WriteToFile(historyPath, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a write makes a backup of the history with the secrets
+	err = Record(Entry{Command: "pwd", Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backups := historyBackups(t, historyPath); backups == 0 {
+		t.Fatal("the history was not backed up")
+	}
+
+	err = Scrub()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Command: "pwd", Count: 1},
+		{Command: "deploy --token=<redacted!secret>", Count: 3},
+		{Command: "ls", Count: 1},
+	}
+	if !reflect.DeepEqual(h.Entries, want) {
+		t.Errorf("scrubbed entries %+v, want %+v", h.Entries, want)
+	}
+	if backups := historyBackups(t, historyPath); backups != 0 {
+		t.Errorf("%d backups of the history are left after the scrub", backups)
+	}
+
+	raw, err := os.ReadFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "abc") || strings.Contains(string(raw), "def") {
+		t.Errorf("the scrubbed history still has the secrets:\n%s", raw)
+	}
+}
+
+func historyBackups(t *testing.T, historyPath string) int {
+	backups, err := reader.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, backup := range backups {
+		if backup.Original == historyPath {
+			count++
+		}
+	}
+	return count
+}
+
+func strPtr(str string) *string {
+	return &str
+}
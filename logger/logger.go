@@ -16,7 +16,9 @@ func SetupLog() *zerolog.Logger {
 		return ll
 	}
 
-	var output = zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "15:04:05"}
+	// stdout is left to the chosen command, the shell wrapper runs what is
+	// printed there
+	var output = zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: "15:04:05"}
 	output.FormatLevel = func(i interface{}) string {
 		return strings.ToUpper(fmt.Sprintf("|%-4s|", i))
 	}
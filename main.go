@@ -1,17 +1,26 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/sebastianxyzsss/tardigrade/action"
+	"github.com/sebastianxyzsss/tardigrade/favorites"
 	"github.com/sebastianxyzsss/tardigrade/globals"
+	"github.com/sebastianxyzsss/tardigrade/history"
 	"github.com/sebastianxyzsss/tardigrade/logger"
 	"github.com/sebastianxyzsss/tardigrade/parser"
 	"github.com/sebastianxyzsss/tardigrade/reader"
+	"github.com/sebastianxyzsss/tardigrade/redacter"
 	"github.com/sebastianxyzsss/tardigrade/selecter"
+	"github.com/sebastianxyzsss/tardigrade/tagger"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 )
 
 type Cli struct {
@@ -21,27 +30,152 @@ type Cli struct {
 	All       bool     `short:"a" help:"filter Anything that contains string, eg. -a somekeyword"`
 	Copy      bool     `short:"c" help:"include flag to only copy to clipboard, for linux install xclip or xsel, eg. -c"`
 	Files     bool     `short:"s" help:"files to read, including urls, separated by spaces, eg. -s file1.yml /some/dir/file2.yml /https://example.com/file3.yml"`
-	Paths     []string `arg:"" optional:"" name:"path" help:"extra strings, could be files, tags, keywords. optional" type:"path"`
+	Query     string   `short:"q" help:"filter with a query, words can have a field (cmd: desc: tag:), a minus to exclude, quotes and OR, eg. -q 'tag:docker -tag:prod'"`
+	Paths     []string `arg:"" optional:"" name:"path" help:"extra strings, could be files, tags, keywords. optional"`
+}
+
+// the commands to manage what tardigrade keeps, they are only taken from the
+// first argument so they never clash with the strings of the chooser
+type Commands struct {
+	History HistoryCmd `cmd:"" help:"History commands"`
+	Restore RestoreCmd `cmd:"" help:"List backups of the files tardigrade rewrote, or restore one, eg. tg restore 2"`
+	Log     LogCmd     `cmd:"" help:"Show the changes made to the tardigrade directory, needs gitversioning in the settings, eg. tg log"`
+	TagsCmd TagsCmd    `cmd:"" name:"tags" help:"List the tags, show the commands with a tag, rename or delete a tag, eg. tg tags"`
+}
+
+type TagsCmd struct {
+	List   struct{}      `cmd:"" default:"1" help:"Print every tag with how many commands have it and where it is written, eg. tg tags"`
+	Show   TagsShowCmd   `cmd:"" help:"Print the commands with a tag, eg. tg tags show docker"`
+	Rename TagsRenameCmd `cmd:"" help:"Rename a tag in the content files of the user home and of the current directory, eg. tg tags rename k8s kubernetes"`
+	Delete TagsDeleteCmd `cmd:"" help:"Remove a tag from the content files of the user home and of the current directory, eg. tg tags delete old"`
+}
+
+type TagsShowCmd struct {
+	Tag string `arg:"" help:"tag to show"`
+}
+
+type TagsRenameCmd struct {
+	Tag    string `arg:"" help:"tag to rename"`
+	NewTag string `arg:"" help:"new name of the tag"`
+}
+
+type TagsDeleteCmd struct {
+	Tag string `arg:"" help:"tag to remove"`
+}
+
+type LogCmd struct {
+	File string `arg:"" optional:"" help:"only changes to this file of the tardigrade directory, eg. tg log tardicontent.yml"`
+}
+
+type HistoryCmd struct {
+	Here bool `help:"only commands chosen in the current directory or repository, eg. tg history --here"`
+
+	List   struct{}         `cmd:"" default:"1" help:"Print the most recent commands, eg. tg history"`
+	Scrub  struct{}         `cmd:"" help:"Rewrite the history applying the current redaction rules, eg. tg history scrub"`
+	Record HistoryRecordCmd `cmd:"" help:"Save how the last chosen command ended, used by the shell wrapper, eg. tg history record --exit-code $?"`
+}
+
+type RestoreCmd struct {
+	Backup string `arg:"" optional:"" help:"number or name of the backup to restore, as listed by tg restore"`
+}
+
+type HistoryRecordCmd struct {
+	ExitCode int     `help:"exit code of the command"`
+	Duration float64 `help:"seconds the command took"`
 }
 
 var CLI Cli
 
+var COMMANDS Commands
+
+// the first argument of each command, as kong names them
+var commandNames = []string{"history", "restore", "log", "tags"}
+
 var ll = logger.SetupLog()
 
 func main() {
 
-	ctx := kong.Parse(&CLI)
+	if !isCommand(os.Args[1:]) {
+		kong.Parse(&CLI, kong.Description("Choose a command. To manage what tardigrade keeps: tg "+strings.Join(commandNames, ", tg ")+", see tg <command> --help"))
+		getOptions(&CLI)
+		runTardigrade(contentFiles(CLI.Paths))
+		return
+	}
+
+	ctx := kong.Parse(&COMMANDS)
 
 	switch ctx.Command() {
-	case "<path>":
-		getOptions(&CLI)
-		runTardigrade(CLI.Paths)
-	default:
-		getOptions(&CLI)
-		runTardigrade(nil)
+	case "history list":
+		loadSettings()
+		printHistory(COMMANDS.History.Here)
+	case "history scrub":
+		loadSettings()
+		err := history.Scrub()
+		if err != nil {
+			ll.Error().Err(err).Msg("unable to scrub history")
+			os.Exit(1)
+		}
+	case "history record":
+		loadSettings()
+		err := history.RecordResult(COMMANDS.History.Record.ExitCode, COMMANDS.History.Record.Duration)
+		if err != nil {
+			ll.Error().Err(err).Msg("unable to record the result in the history")
+			os.Exit(1)
+		}
+	case "restore":
+		loadSettings()
+		printBackups()
+	case "restore <backup>":
+		loadSettings()
+		restoreBackup(COMMANDS.Restore.Backup)
+	case "log", "log <file>":
+		loadSettings()
+		changeLog, err := reader.ChangeLog(COMMANDS.Log.File)
+		if err != nil {
+			ll.Error().Err(err).Msg("unable to show the changes")
+			os.Exit(1)
+		}
+		fmt.Print(changeLog)
+	case "tags list":
+		loadSettings()
+		printTags()
+	case "tags show <tag>":
+		loadSettings()
+		printTagCarriers(COMMANDS.TagsCmd.Show.Tag)
+	case "tags rename <tag> <new-tag>":
+		loadSettings()
+		count, err := tagger.Rename(COMMANDS.TagsCmd.Rename.Tag, COMMANDS.TagsCmd.Rename.NewTag)
+		if err != nil {
+			ll.Error().Err(err).Msg("unable to rename the tag")
+			os.Exit(1)
+		}
+		ll.Info().Msg("renamed " + strconv.Itoa(count) + " times")
+	case "tags delete <tag>":
+		loadSettings()
+		count, err := tagger.Delete(COMMANDS.TagsCmd.Delete.Tag)
+		if err != nil {
+			ll.Error().Err(err).Msg("unable to delete the tag")
+			os.Exit(1)
+		}
+		ll.Info().Msg("deleted " + strconv.Itoa(count) + " times")
 	}
 }
 
+// a command is only the first argument, before any flag, so tg -t log and
+// tg -a tags still filter; a content file with the name of a command is read
+// with its path, like tg ./log
+func isCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	for _, name := range commandNames {
+		if args[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
 func getOptions(object interface{}) {
 	cli, ok := object.(*Cli)
 	if ok {
@@ -57,22 +191,23 @@ func getOptions(object interface{}) {
 			ll.Debug().Msg("set strings as Tag filters, set to parse flat")
 			globals.FlatParse = true
 			globals.FilterAction = globals.FilterTags
-			for _, path := range CLI.Paths {
-				globals.FilterStrings = append(globals.FilterStrings, filepath.Base(path))
-			}
+			globals.FilterStrings = append(globals.FilterStrings, CLI.Paths...)
 		}
 		if cli.All {
 			ll.Debug().Msg("set strings as All filters, in anything, set to parse flat")
 			globals.FlatParse = true
 			globals.FilterAction = globals.FilterAnything
-			for _, path := range CLI.Paths {
-				globals.FilterStrings = append(globals.FilterStrings, filepath.Base(path))
-			}
+			globals.FilterStrings = append(globals.FilterStrings, CLI.Paths...)
+		}
+		if cli.Query != "" {
+			ll.Debug().Msg("set query filter, set to parse flat")
+			globals.FlatParse = true
+			globals.FilterAction = globals.FilterQuery
+			globals.FilterStrings = append(globals.FilterStrings, cli.Query)
 		}
 		if cli.Copy {
 			ll.Info().Msg("will only copy to clipboard")
-			globals.RunMode = "copy-paste"
-			globals.RunAction = action.CopyPaster{}
+			globals.RunMode = action.ModeCopy
 		}
 		if cli.Init {
 			ll.Info().Msg("creating new local file")
@@ -84,6 +219,115 @@ func getOptions(object interface{}) {
 	}
 }
 
+func printHistory(here bool) {
+	userHistory, err := history.Load()
+	if err != nil {
+		ll.Error().Err(err).Msg("unable to read the history")
+		os.Exit(1)
+	}
+
+	commands := userHistory.Commands(globals.HistoryStoreSize)
+	if here {
+		dir, err := os.Getwd()
+		if err != nil {
+			ll.Error().Err(err).Msg("unable to get the working directory")
+			os.Exit(1)
+		}
+		commands = userHistory.CommandsHere(dir, globals.HistoryStoreSize)
+	}
+
+	for _, command := range commands {
+		fmt.Println(command)
+	}
+}
+
+func printBackups() {
+	backups, err := reader.ListBackups()
+	if err != nil {
+		ll.Error().Err(err).Msg("unable to list the backups")
+		os.Exit(1)
+	}
+	if len(backups) == 0 {
+		fmt.Println("no backups yet")
+		return
+	}
+	for i, backup := range backups {
+		fmt.Printf("%3d  %s  %s\n", i+1, backup.Time.Format("2006-01-02 15:04:05"), backup.Original)
+	}
+}
+
+func restoreBackup(which string) {
+	backups, err := reader.ListBackups()
+	if err != nil {
+		ll.Error().Err(err).Msg("unable to list the backups")
+		os.Exit(1)
+	}
+
+	var chosen *reader.Backup
+	for i := range backups {
+		if backups[i].Name == which || strconv.Itoa(i+1) == which {
+			chosen = &backups[i]
+			break
+		}
+	}
+	if chosen == nil {
+		ll.Error().Msg("there is no backup " + which + ", see tg restore")
+		os.Exit(1)
+	}
+
+	err = reader.RestoreBackup(*chosen)
+	if err != nil {
+		ll.Error().Err(err).Msg("unable to restore the backup")
+		os.Exit(1)
+	}
+	ll.Info().Msg("restored " + chosen.Original + " from " + chosen.Time.Format("2006-01-02 15:04:05"))
+
+	err = reader.CommitChange("restore: "+filepath.Base(chosen.Original)+" from "+chosen.Time.Format("2006-01-02 15:04:05"), chosen.Original)
+	if err != nil {
+		ll.Warn().Err(err).Msg("unable to version the change")
+	}
+}
+
+// the content of the user home and the current directory, without the history
+func loadContent() *parser.Element {
+	yamlAsMap := reader.GetRawMapContent(nil)
+	parser.Sources = reader.Source
+
+	rootElement := parser.NewElement("root", false, nil)
+	err := parser.MainRecurseMap(*yamlAsMap, rootElement)
+	if err != nil {
+		ll.Error().Err(err).Msg("unable to read the content")
+		os.Exit(1)
+	}
+	return rootElement
+}
+
+func printTags() {
+	tags := tagger.List(loadContent())
+	if len(tags) == 0 {
+		fmt.Println("no tags yet")
+		return
+	}
+	for _, tag := range tags {
+		fmt.Printf("%-20s %4d commands  %4d direct  %4d inherited  %s\n", tag.Tag, tag.Commands(), tag.Direct, tag.Inherited, strings.Join(tag.Files, ", "))
+	}
+}
+
+func printTagCarriers(tag string) {
+	carriers := tagger.Carriers(loadContent(), tag)
+	if len(carriers) == 0 {
+		fmt.Println("no commands with the tag " + tag)
+		return
+	}
+	for _, carrier := range carriers {
+		how := "inherited"
+		if carrier.Direct {
+			how = "direct"
+		}
+		fmt.Printf("%-9s  %s  %s\n", how, parser.ElementPath(carrier.Element.Parent), redacter.Redact(parser.CommandString(carrier.Element)))
+	}
+}
+
 type conf struct {
 	Settings globals.Settings
 }
@@ -103,15 +347,29 @@ func createSettings() (*globals.Settings, error) {
 	if reader.DoesFileNotExist(configPath) {
 		ll.Debug().Msg("seeting file does not exist, creating")
 
-		viper.SetDefault("settings", globals.Settings{
+		defaults := conf{Settings: globals.Settings{
 			Height:           11,
-			IndicatorStyle:   "80",
 			FooterKeyMaxSize: 16,
 			HistorySize:      11,
+			HistoryStoreSize: 1000,
 			LogLevel:         "info",
-		})
+			Ranking:          "blended",
+			BackupCount:      10,
+			Preview:          "off",
+			Layout:           "list",
+			ThemePreset:      "auto",
+			Redactions:       redacter.DefaultPatterns,
+		}}
 
-		viper.WriteConfig()
+		viper.SetDefault("settings", defaults.Settings)
+
+		defaultsYaml, err := yaml.Marshal(defaults)
+		if err == nil {
+			err = reader.WriteToFile(configPath, string(defaultsYaml))
+		}
+		if err != nil {
+			ll.Error().Err(err).Msg("unable to create the settings file")
+		}
 	}
 
 	c := &conf{}
@@ -126,7 +384,7 @@ func createSettings() (*globals.Settings, error) {
 	return &c.Settings, nil
 }
 
-func runTardigrade(strsToRead []string) {
+func loadSettings() *globals.Settings {
 
 	settings, err := createSettings()
 	if err != nil {
@@ -136,6 +394,14 @@ func runTardigrade(strsToRead []string) {
 
 	globals.ChildKeyMaxSize = settings.FooterKeyMaxSize
 	globals.HistorySize = settings.HistorySize
+	if settings.HistoryStoreSize > 0 {
+		globals.HistoryStoreSize = settings.HistoryStoreSize
+	}
+	if settings.BackupCount != 0 {
+		globals.BackupCount = settings.BackupCount
+	}
+	globals.GitVersioning = settings.GitVersioning
+	globals.GitHistory = settings.GitHistory
 
 	if settings.LogLevel == "debug" {
 		logger.SetLogLevelDebug()
@@ -143,12 +409,56 @@ func runTardigrade(strsToRead []string) {
 		logger.SetLogLevelInfo()
 	}
 
+	parser.SetTagAliases(settings.TagAliases)
+
+	if settings.Redactions != nil {
+		err = redacter.SetPatterns(settings.Redactions)
+		if err != nil {
+			ll.Error().Err(err).Msg("there is an invalid pattern in the redactions settings")
+			os.Exit(1)
+		}
+	}
+
+	return settings
+}
+
+// the strings are only files to read without -t, -a or -q, those take them
+// as filters as they are written; urls are left as they are
+func contentFiles(strs []string) []string {
+	if globals.FilterAction != globals.FilterFiles && globals.FilterAction != globals.FilterNone {
+		return strs
+	}
+	files := make([]string, 0, len(strs))
+	for _, str := range strs {
+		if strings.Contains(str, "http://") || strings.Contains(str, "https://") {
+			files = append(files, str)
+			continue
+		}
+		files = append(files, kong.ExpandPath(str))
+	}
+	return files
+}
+
+func runTardigrade(strsToRead []string) {
+
+	settings := loadSettings()
+
 	ll.Debug().Msg("-------------------------------------------------------- tardigrade")
 
 	reader.CreateNewUserContentFile()
 
 	yamlAsMap := reader.GetRawMapContent(strsToRead)
 
+	userHistory, err := history.Load()
+	if err != nil {
+		ll.Error().Err(err).Msg("unable to read the history")
+	} else {
+		historyAsMap := userHistory.AsMap(globals.HistorySize)
+		yamlAsMap = reader.AppendMap(yamlAsMap, &historyAsMap)
+	}
+
+	parser.Sources = reader.Source
+
 	rootElement := parser.NewElement("root", false, nil)
 	err = parser.MainRecurseMap(*yamlAsMap, rootElement)
 	if err != nil {
@@ -158,7 +468,26 @@ func runTardigrade(strsToRead []string) {
 	ll.Debug().Msg("-----------")
 	ll.Debug().Msg("-----------")
 
-	selecter.Chooser(rootElement, settings)
+	if userHistory != nil {
+		parser.LastUsed = func(element *parser.Element) time.Time {
+			used, _ := userHistory.Usage(element)
+			return used
+		}
+	}
+	parser.AddSmartGroups(rootElement, settings.Smart)
+
+	index := parser.NewIndex(rootElement)
+	if userHistory != nil {
+		parser.AddVirtualGroup(rootElement, parser.RecentGroup, userHistory.Recent(index, globals.HistorySize))
+	}
+	userFavorites, err := favorites.Load()
+	if err != nil {
+		ll.Error().Err(err).Msg("unable to read the favorites")
+	} else {
+		parser.AddVirtualGroup(rootElement, parser.FavoritesGroup, userFavorites.Elements(index))
+	}
+
+	selecter.Chooser(rootElement, settings, userHistory, userFavorites)
 
 	ll.Debug().Msg("------------------------------------------------------")
 }
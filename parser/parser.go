@@ -3,24 +3,45 @@ package parser
 import (
 	"fmt"
 	"reflect"
-	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/sebastianxyzsss/tardigrade/globals"
 	"github.com/sebastianxyzsss/tardigrade/logger"
+	"github.com/sebastianxyzsss/tardigrade/redacter"
 	"github.com/sebastianxyzsss/tardigrade/utils"
 )
 
 var ll = logger.SetupLog()
 
-var HistoryTemp *Element = nil
+const PathSeparator = "/"
+
+// file:line where the element of a path, under the top group top, was
+// declared, set by the reader
+var Sources func(top string, path string) string = nil
+
+// groups made from the history, they are left out of flat mode
+const (
+	HistoryGroup     = "history"
+	HistoryHereGroup = "history here"
+)
+
+// groups of elements that already are in the tree, added on top of the root
+const (
+	FavoritesGroup = "★ favorites"
+	RecentGroup    = "recent"
+)
 
 // represents one element in the yaml file that could be a group or a command
 type Element struct {
 	Content        string
 	IsCommand      bool
+	Expanded       bool
+	Virtual        bool
 	Description    string
 	Tags           []string
+	DirectTags     []string
+	Source         string
 	ChildKeys      *[]string
 	Parent         *Element
 	ChildrenSorted []*Element
@@ -53,19 +74,16 @@ func MainRecurseMap(m map[interface{}]interface{}, parent *Element) error {
 
 	RecurseMap(m, parent)
 
-	HistoryTemp = parent.Children["history"]
+	// the groups show the same commands as flat mode
+	if launchFiltered() {
+		pruneToPreCriteria(parent)
+	}
 
+	// the tree is kept, flat mode is built from it so it can be left again
 	if globals.FlatParse {
 		ll.Debug().Msg("Flat Parse !!")
 
-		flatParent := NewFlatParent()
-
-		PostProcess(parent, flatParent)
-
-		parent.Children = flatParent.Children
-		parent.ChildrenSorted = flatParent.ChildrenSorted
-
-		if len(parent.ChildrenSorted) < 1 {
+		if len(Flatten(parent).ChildrenSorted) < 1 {
 			return fmt.Errorf("No children found")
 		}
 	}
@@ -73,19 +91,20 @@ func MainRecurseMap(m map[interface{}]interface{}, parent *Element) error {
 	return nil
 }
 
+// all the commands of the tree in one group, the tree is not changed
+func Flatten(root *Element) *Element {
+	flatParent := NewFlatParent()
+	PostProcess(root, flatParent)
+	return flatParent
+}
+
 func PostProcess(element *Element, flatParent *Element) {
-	if element.Content == "history" {
+	if element.Virtual || IsHistoryGroup(element) {
 		return
 	}
 	if element.IsCommand {
 		if element.Parent != nil {
-			if !DoesElementHaveCommandTag(element.Parent) {
-				appendToFlatParent(flatParent, element)
-			} else {
-				content := utils.ReplaceContentWithChoices(element.Parent.Content, element.Content)
-				element.Content = content
-				appendToFlatParent(flatParent, element)
-			}
+			appendToFlatParent(flatParent, expand(element))
 		}
 	}
 
@@ -94,6 +113,42 @@ func PostProcess(element *Element, flatParent *Element) {
 	}
 }
 
+// a command of a group with the comm tag as it is run, with the template of
+// the group filled in, other commands as they are
+func expand(element *Element) *Element {
+	if element.Parent == nil || !DoesElementHaveCommandTag(element.Parent) {
+		return element
+	}
+	expanded := *element
+	expanded.Content = utils.ReplaceContentWithChoices(element.Parent.Content, element.Content)
+	expanded.Expanded = true
+	return &expanded
+}
+
+// drops the commands left out by the launch filters, and the groups that
+// end up empty
+func pruneToPreCriteria(element *Element) {
+	childrenSorted := make([]*Element, 0, len(element.ChildrenSorted))
+	childKeys := make([]string, 0, len(element.ChildrenSorted))
+	for _, child := range element.ChildrenSorted {
+		if child.IsCommand && !elementPassesPreCriteria(expand(child)) {
+			delete(element.Children, child.Content)
+			continue
+		}
+		if !child.IsCommand {
+			pruneToPreCriteria(child)
+			if len(child.ChildrenSorted) == 0 {
+				delete(element.Children, child.Content)
+				continue
+			}
+		}
+		childrenSorted = append(childrenSorted, child)
+		childKeys = append(childKeys, TruncateString(child.Content, globals.ChildKeyMaxSize))
+	}
+	element.ChildrenSorted = childrenSorted
+	element.ChildKeys = &childKeys
+}
+
 func appendToFlatParent(flatParent *Element, element *Element) {
 	if elementPassesPreCriteria(element) {
 		flatParent.ChildrenSorted = append(flatParent.ChildrenSorted, element)
@@ -101,10 +156,27 @@ func appendToFlatParent(flatParent *Element, element *Element) {
 	}
 }
 
+// if the element is one of the history groups at the top of the tree
+func IsHistoryGroup(element *Element) bool {
+	return element.Parent != nil && element.Parent.Parent == nil &&
+		(element.Content == HistoryGroup || element.Content == HistoryHereGroup)
+}
+
+// the groups of a map are added by name, so they are in the same order every
+// time, the commands of a list keep their order
 func RecurseMap(m map[interface{}]interface{}, parent *Element) {
+	keys := make([]string, 0, len(m))
+	values := make(map[string]interface{}, len(m))
 	for key, val := range m {
-		valType := reflect.TypeOf(val)
 		keyStr := fmt.Sprintf("%v", key)
+		keys = append(keys, keyStr)
+		values[keyStr] = val
+	}
+	sort.Strings(keys)
+
+	for _, keyStr := range keys {
+		val := values[keyStr]
+		valType := reflect.TypeOf(val)
 		element := NewElement(keyStr, false, parent)
 		if valType.Kind() == reflect.Map {
 			processElement(element, parent)
@@ -124,6 +196,87 @@ func RecurseSlice(lst []interface{}, parent *Element) {
 	}
 }
 
+// adds a group at the top of root made of elements that are somewhere else in
+// the tree, they keep their own parents so they run and are recorded as usual
+func AddVirtualGroup(root *Element, name string, elements []*Element) *Element {
+	group := NewElement(name, false, root)
+	group.Virtual = true
+	root.Children[name] = group
+	SetVirtualChildren(group, elements)
+	return group
+}
+
+// replaces the elements of a virtual group, a group left out of its parent
+// while it was empty goes back on top
+func SetVirtualChildren(group *Element, elements []*Element) {
+	childKeys := make([]string, 0, len(elements))
+	group.Children = make(map[string]*Element)
+	group.ChildrenSorted = make([]*Element, 0, len(elements))
+	for _, element := range elements {
+		group.Children[element.Content] = element
+		group.ChildrenSorted = append(group.ChildrenSorted, element)
+		childKeys = append(childKeys, TruncateString(element.Content, globals.ChildKeyMaxSize))
+	}
+	group.ChildKeys = &childKeys
+
+	parent := group.Parent
+	for _, child := range parent.ChildrenSorted {
+		if child == group {
+			return
+		}
+	}
+	parent.ChildrenSorted = append([]*Element{group}, parent.ChildrenSorted...)
+	parentChildKeys := append([]string{TruncateString(group.Content, globals.ChildKeyMaxSize)}, *parent.ChildKeys...)
+	parent.ChildKeys = &parentChildKeys
+}
+
+// every element under element, groups before what is inside them, virtual
+// groups and their elements are left out
+func Walk(element *Element, visit func(*Element)) {
+	for _, child := range element.ChildrenSorted {
+		if child.Virtual {
+			continue
+		}
+		visit(child)
+		Walk(child, visit)
+	}
+}
+
+// finds elements of the tree by path, and commands by what they run when the
+// path is gone
+type Index struct {
+	byPath    map[string]*Element
+	byCommand map[string]*Element
+}
+
+func NewIndex(root *Element) Index {
+	index := Index{byPath: make(map[string]*Element), byCommand: make(map[string]*Element)}
+	Walk(root, func(element *Element) {
+		path := redacter.Redact(ElementPath(element))
+		if IsPathInGroup(path, HistoryGroup) || IsPathInGroup(path, HistoryHereGroup) {
+			return
+		}
+		index.byPath[path] = element
+		if element.IsCommand {
+			command := redacter.Redact(CommandString(element))
+			if _, ok := index.byCommand[command]; !ok {
+				index.byCommand[command] = element
+			}
+		}
+	})
+	return index
+}
+
+func (index Index) Find(path string, command string) *Element {
+	if element, ok := index.byPath[path]; ok {
+		return element
+	}
+	if command != "" {
+		return index.byCommand[command]
+	}
+	return nil
+}
+
 func NewFlatParent() *Element {
 	return NewElement("all", false, nil)
 }
@@ -137,6 +290,7 @@ func NewElement(key string, isCommand bool, parent *Element) *Element {
 		Content:        key,
 		IsCommand:      isCommand,
 		Tags:           tags,
+		DirectTags:     make([]string, 0),
 		ChildKeys:      &childKeys,
 		Children:       children,
 		ChildrenSorted: childrenSorted,
@@ -158,11 +312,15 @@ func processElement(element *Element, parent *Element) {
 		element.Description = currentDescription
 		tags := getTagsFromDescription(currentDescription)
 		element.Tags = tags
+		element.DirectTags = append([]string{}, tags...)
 		processElementToParent(element, parent, currentContent)
 	}
 }
 
 func processElementToParent(element *Element, parent *Element, elementContent string) {
+	if Sources != nil {
+		element.Source = Sources(TopGroup(element), ElementPath(element))
+	}
 	parentChildKeys := *parent.ChildKeys
 	parentChildKeys = append(parentChildKeys, TruncateString(element.Content, globals.ChildKeyMaxSize))
 	parent.ChildKeys = &parentChildKeys
@@ -177,17 +335,16 @@ func processElementToParent(element *Element, parent *Element, elementContent st
 	}
 }
 
-func elementPassesPreCriteria(element *Element) bool {
+// if the launch flags filter the commands, with -t, -a or -q
+func launchFiltered() bool {
+	return globals.FilterAction == globals.FilterTags || globals.FilterAction == globals.FilterAnything || globals.FilterAction == globals.FilterQuery
+}
 
-	if globals.FilterAction == globals.FilterTags {
-		if element.IsCommand == true && !AreFilterStringsInTags(element.Tags) {
-			return false
-		}
-	}
+func elementPassesPreCriteria(element *Element) bool {
 
-	if globals.FilterAction == globals.FilterAnything {
-		others := []string{element.Content, element.Description}
-		if element.IsCommand == true && !AreFilterStringsInTags(element.Tags) && !AreFilterStringsInTags(others) {
+	if launchFiltered() {
+		if element.IsCommand == true && !LaunchQuery().Matches(element) {
+			ll.Debug().Str("element", redacter.Redact(element.Content)).Msg("filtered out")
 			return false
 		}
 	}
@@ -198,29 +355,18 @@ func elementPassesPreCriteria(element *Element) bool {
 	return true
 }
 
-func AreFilterStringsInTags(strs []string) bool {
-
-	if len(globals.FilterStrings) > 0 {
-		for _, filterString := range globals.FilterStrings {
-			for _, str := range strs {
-				if strings.Contains(str, filterString) {
-					ll.Debug().Str("str", str).Str("filterString", filterString).Msg("match")
-					return true
-				}
-			}
-		}
+// the query made by the launch filters, each filter string is an alternative,
+// plain words are tags with -t and anything otherwise
+func LaunchQuery() Query {
+	field := FieldAll
+	if globals.FilterAction == globals.FilterTags {
+		field = FieldTag
 	}
-	return false
-}
-
-func getTagsFromDescription(description string) []string {
-	re := regexp.MustCompile("@\\S+")
-	tags := re.FindAllString(description, -1)
-	// cleaning at sign
-	for i := 0; i < len(tags); i++ {
-		tags[i] = strings.Replace(tags[i], "@", "", 1)
+	query := Query{Groups: make([][]Term, 0)}
+	for _, filterString := range globals.FilterStrings {
+		query.Groups = append(query.Groups, ParseQuery(filterString, field).Groups...)
 	}
-	return tags
+	return query
 }
 
 func DoesElementHaveCommandTag(element *Element) bool {
@@ -237,6 +383,51 @@ func areAnyTagsCommand(tags []string) bool {
 	return false
 }
 
+// returns the command that a command element stands for, filling in the
+// template of its parent when the parent has the comm tag
+func CommandString(element *Element) string {
+	if element.Parent != nil && !element.Expanded && DoesElementHaveCommandTag(element.Parent) {
+		return utils.ReplaceContentWithChoices(element.Parent.Content, element.Content)
+	}
+	return element.Content
+}
+
+// the contents from the top group down to the element, separated by slashes
+func ElementPath(element *Element) string {
+	parts := make([]string, 0)
+	for e := element; e != nil && e.Parent != nil; e = e.Parent {
+		parts = append([]string{e.Content}, parts...)
+	}
+	return strings.Join(parts, PathSeparator)
+}
+
+// the content of the group under the root the element is in, the element
+// itself for a top group; it may have slashes, unlike the parts of a path
+func TopGroup(element *Element) string {
+	e := element
+	for e != nil && e.Parent != nil && e.Parent.Parent != nil {
+		e = e.Parent
+	}
+	if e == nil {
+		return ""
+	}
+	return e.Content
+}
+
+// the contents from the root down to the element, each one truncated, to show
+// where the element is
+func Breadcrumb(element *Element, separator string) string {
+	parts := make([]string, 0)
+	for e := element; e != nil; e = e.Parent {
+		parts = append([]string{TruncateString(e.Content, globals.ChildKeyMaxSize)}, parts...)
+	}
+	return strings.Join(parts, separator)
+}
+
+func IsPathInGroup(path string, group string) bool {
+	return path == group || strings.HasPrefix(path, group+PathSeparator)
+}
+
 func GetChildKeysAsString(element *Element) string {
 	asString := strings.Join(getChildKeys(element), " | ")
 	return "| " + asString + " |"
@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// the parts of an element a query term can look in
+const (
+	FieldCmd    = "cmd"
+	FieldDesc   = "desc"
+	FieldTag    = "tag"
+	FieldAll    = "all"
+	FieldRecent = "recent" // chosen within a time, like recent:7d or recent:12h
+)
+
+var queryFields = []string{FieldCmd, FieldDesc, FieldTag, FieldAll, FieldRecent}
+
+// when an element was last chosen, zero when never, set from the history
+var LastUsed func(element *Element) time.Time = nil
+
+// one condition of a query, like tag:docker or -desc:"clean up"; a word
+// looking in the tags without tag: only has to be in a tag
+type Term struct {
+	Field   string
+	Value   string
+	Negated bool
+	Partial bool
+}
+
+// a parsed search, the terms of a group must all match and any group matching
+// is enough, so "a b OR c" is (a AND b) OR c
+type Query struct {
+	Groups [][]Term
+	plain  bool
+}
+
+// a word of the query, literal is how much of the text came before any quote,
+// only that part can hold a negation or a field
+type queryToken struct {
+	text    string
+	literal int
+}
+
+// parses a query, words without a field look in defaultField
+func ParseQuery(str string, defaultField string) Query {
+	query := Query{Groups: make([][]Term, 0), plain: true}
+	group := make([]Term, 0)
+
+	for _, token := range tokenizeQuery(str) {
+		if token.literal < len(token.text) {
+			query.plain = false
+		}
+		if token.literal == len(token.text) && (token.text == "OR" || token.text == "AND") {
+			query.plain = false
+			if token.text == "OR" && len(group) > 0 {
+				query.Groups = append(query.Groups, group)
+				group = make([]Term, 0)
+			}
+			continue
+		}
+
+		term := Term{Field: defaultField, Value: token.text}
+		literal := token.literal
+		if literal > 0 && len(term.Value) > 1 && strings.HasPrefix(term.Value, "-") {
+			term.Negated = true
+			term.Value = term.Value[1:]
+			literal--
+		}
+		fielded := false
+		if i := strings.Index(term.Value[:literal], ":"); i > 0 && isQueryField(strings.ToLower(term.Value[:i])) {
+			term.Field = strings.ToLower(term.Value[:i])
+			term.Value = term.Value[i+1:]
+			fielded = true
+		}
+		// a minus only excludes before a field or a quote, so flags like -la
+		// are looked for as they are
+		if term.Negated && !fielded && literal > 0 {
+			term.Negated = false
+			term.Value = token.text
+		}
+		if term.Value == "" {
+			continue
+		}
+		term.Partial = !fielded
+		if term.Negated || term.Field != defaultField || term.Value != token.text {
+			query.plain = false
+		}
+		group = append(group, term)
+	}
+
+	if len(group) > 0 {
+		query.Groups = append(query.Groups, group)
+	}
+	return query
+}
+
+func tokenizeQuery(str string) []queryToken {
+	tokens := make([]queryToken, 0)
+	var current strings.Builder
+	literal := -1
+	inQuotes, started := false, false
+
+	flush := func() {
+		if started {
+			if literal < 0 {
+				literal = current.Len()
+			}
+			tokens = append(tokens, queryToken{text: current.String(), literal: literal})
+		}
+		current.Reset()
+		literal = -1
+		started = false
+	}
+
+	for _, r := range str {
+		switch {
+		case r == '"':
+			if literal < 0 {
+				literal = current.Len()
+			}
+			inQuotes = !inQuotes
+			started = true
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func isQueryField(field string) bool {
+	for _, f := range queryFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// true when the query is only words, without fields, negations, quotes or
+// operators, so it can be matched as plain text
+func (q Query) Plain() bool {
+	return q.plain
+}
+
+func (q Query) IsEmpty() bool {
+	return len(q.Groups) == 0
+}
+
+// an empty query matches everything
+func (q Query) Matches(element *Element) bool {
+	if q.IsEmpty() {
+		return true
+	}
+	for _, group := range q.Groups {
+		if groupMatches(group, element) {
+			return true
+		}
+	}
+	return false
+}
+
+func groupMatches(group []Term, element *Element) bool {
+	for _, term := range group {
+		if term.matches(element) == term.Negated {
+			return false
+		}
+	}
+	return true
+}
+
+// if the value is in the field, ignoring case, tags are matched as tags,
+// negation is left to the caller
+func (t Term) matches(element *Element) bool {
+	if t.Field == FieldRecent {
+		within, err := parseAge(t.Value)
+		if err != nil || LastUsed == nil {
+			return false
+		}
+		used := LastUsed(element)
+		return !used.IsZero() && time.Since(used) <= within
+	}
+	if t.Field == FieldTag || t.Field == FieldAll {
+		for _, tag := range element.Tags {
+			if t.matchesTag(tag) {
+				return true
+			}
+		}
+		if t.Field == FieldTag {
+			return false
+		}
+	}
+
+	value := strings.ToLower(t.Value)
+	for _, text := range fieldTexts(element, t.Field) {
+		if strings.Contains(strings.ToLower(text), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// tag: terms find the tag and the tags under it, other words only have to be
+// in the tag
+func (t Term) matchesTag(tag string) bool {
+	if t.Field == FieldTag && !t.Partial {
+		return TagMatches(tag, t.Value)
+	}
+	return TagContains(tag, t.Value)
+}
+
+// a duration like 90m, 12h or 7d
+func parseAge(str string) (time.Duration, error) {
+	if strings.HasSuffix(str, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(str, "d"))
+		return time.Duration(n) * 24 * time.Hour, err
+	}
+	return time.ParseDuration(str)
+}
+
+func fieldTexts(element *Element, field string) []string {
+	switch field {
+	case FieldCmd:
+		return []string{element.Content}
+	case FieldDesc:
+		return []string{element.Description}
+	}
+	return []string{element.Content, element.Description}
+}
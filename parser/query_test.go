@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query  string
+		field  string
+		groups [][]Term
+		plain  bool
+	}{
+		{"docker", FieldAll, [][]Term{{{Field: FieldAll, Value: "docker", Partial: true}}}, true},
+		{"tag:docker -tag:prod", FieldAll, [][]Term{{
+			{Field: FieldTag, Value: "docker"},
+			{Field: FieldTag, Value: "prod", Negated: true},
+		}}, false},
+		{`desc:"clean up" OR cmd:kubectl`, FieldAll, [][]Term{
+			{{Field: FieldDesc, Value: "clean up"}},
+			{{Field: FieldCmd, Value: "kubectl"}},
+		}, false},
+		{`-"rm -rf"`, FieldAll, [][]Term{{{Field: FieldAll, Value: "rm -rf", Negated: true, Partial: true}}}, false},
+		// a minus before a plain word is part of it, like a flag
+		{"ls -la", FieldAll, [][]Term{{
+			{Field: FieldAll, Value: "ls", Partial: true},
+			{Field: FieldAll, Value: "-la", Partial: true},
+		}}, true},
+		// slashes are kept, for tags with namespaces and paths
+		{"tag:env/prod desc:a/b", FieldAll, [][]Term{{
+			{Field: FieldTag, Value: "env/prod"},
+			{Field: FieldDesc, Value: "a/b"},
+		}}, false},
+		{"env/prod", FieldTag, [][]Term{{{Field: FieldTag, Value: "env/prod", Partial: true}}}, true},
+		{"a AND b OR", FieldAll, [][]Term{{
+			{Field: FieldAll, Value: "a", Partial: true},
+			{Field: FieldAll, Value: "b", Partial: true},
+		}}, false},
+		{"url:x", FieldAll, [][]Term{{{Field: FieldAll, Value: "url:x", Partial: true}}}, true},
+		{"tag:", FieldAll, [][]Term{}, true},
+		{"", FieldAll, [][]Term{}, true},
+	}
+
+	for _, test := range tests {
+		query := ParseQuery(test.query, test.field)
+		if !reflect.DeepEqual(query.Groups, test.groups) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", test.query, query.Groups, test.groups)
+		}
+		if query.Plain() != test.plain {
+			t.Errorf("ParseQuery(%q).Plain() = %v, want %v", test.query, query.Plain(), test.plain)
+		}
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	element := &Element{
+		Content:     "kubectl delete pod",
+		Description: "clean up the pods @k8s @env/prod",
+		Tags:        []string{"k8s", "env/prod"},
+		IsCommand:   true,
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"kubectl", true},
+		{"cmd:kubectl", true},
+		{"cmd:clean", false},
+		{`desc:"clean up"`, true},
+		{`desc:"up clean"`, false},
+		{"tag:env", true},
+		{"tag:env/prod", true},
+		{"tag:env/dev", false},
+		{"tag:en", false},
+		{"tag:en*", true},
+		{"-tag:prod", true},
+		{"-tag:env", false},
+		{"tag:docker OR cmd:kubectl", true},
+		{"tag:docker OR cmd:helm", false},
+		{"kubectl -cmd:delete", false},
+		{"KUBECTL POD", true},
+	}
+
+	for _, test := range tests {
+		if got := ParseQuery(test.query, FieldAll).Matches(element); got != test.want {
+			t.Errorf("ParseQuery(%q).Matches() = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package parser
+
+import "sort"
+
+// adds a virtual group on top of root for each saved search of the settings,
+// a name and a query like prod-ops: "tag:prod tag:ops", with the commands of
+// the whole tree the query finds, as in flat mode
+func AddSmartGroups(root *Element, searches map[string]string) {
+	names := make([]string, 0, len(searches))
+	for name := range searches {
+		names = append(names, name)
+	}
+	// each group goes on top, the last added is the first one
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	commands := Flatten(root).ChildrenSorted
+	for _, name := range names {
+		query := ParseQuery(searches[name], FieldAll)
+		found := make([]*Element, 0)
+		for _, command := range commands {
+			if query.Matches(command) {
+				found = append(found, command)
+			}
+		}
+		ll.Debug().Int("found", len(found)).Msg("smart group " + name)
+		AddVirtualGroup(root, name, found)
+	}
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// a tag is an at sign followed by names separated by slashes, like @docker or
+// @env/prod, each slash opens a namespace; a name is letters, digits, _ + -
+// and dots, like @node.js, a dot at the end ends the sentence, not the name
+const tagName = `[\p{L}\p{N}_+-](?:[\p{L}\p{N}_.+-]*[\p{L}\p{N}_+-])?`
+
+var tagRegexp = regexp.MustCompile(`@(` + tagName + `(?:/` + tagName + `)*)`)
+
+var wholeTagRegexp = regexp.MustCompile(`^` + tagName + `(?:/` + tagName + `)*$`)
+
+// each alias and the tag it stands for, all in lower case
+var tagAliases = make(map[string]string)
+
+func getTagsFromDescription(description string) []string {
+	tags := make([]string, 0)
+	for _, found := range tagRegexp.FindAllStringSubmatch(description, -1) {
+		tags = append(tags, found[1])
+	}
+	return tags
+}
+
+// if str can be written as a tag, without the at sign
+func IsValidTag(str string) bool {
+	return wholeTagRegexp.MatchString(str)
+}
+
+// where the tag markers of a description are, for each one the start and end
+// of the marker, at sign included, and of the tag
+func TagMarkers(description string) [][]int {
+	return tagRegexp.FindAllStringSubmatchIndex(description, -1)
+}
+
+// the part of tag under the namespace the filter selects, with its slash,
+// like /prod for env/prod and env, or "" when the filter is the whole tag;
+// ok is false when the filter does not select the tag or ends in *
+func TagRest(tag string, filter string) (rest string, ok bool) {
+	if strings.HasSuffix(filter, "*") || !TagMatches(tag, filter) {
+		return "", false
+	}
+	filter = CanonicalTag(filter)
+	names := strings.Split(tag, "/")
+	for n := 1; n <= len(names); n++ {
+		if CanonicalTag(strings.Join(names[:n], "/")) == filter {
+			rest = strings.Join(names[n:], "/")
+			if rest != "" {
+				rest = "/" + rest
+			}
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// sets the alias table, each tag with the other names it goes by, like
+// kubernetes: [k8s, kube]
+func SetTagAliases(aliases map[string][]string) {
+	tagAliases = make(map[string]string)
+	for tag, names := range aliases {
+		canonical := strings.ToLower(tag)
+		for _, name := range names {
+			tagAliases[strings.ToLower(name)] = canonical
+		}
+	}
+}
+
+// CanonicalTag is the tag in lower case with every name replaced by the tag
+// it is an alias of
+func CanonicalTag(tag string) string {
+	names := strings.Split(strings.ToLower(tag), "/")
+	for i, name := range names {
+		if canonical, ok := tagAliases[name]; ok {
+			names[i] = canonical
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+// if the filter selects the tag, ignoring case and aliases: the filter is the
+// tag or one of its namespaces, so env selects env/prod, and a filter ending
+// in * selects the tags starting with it
+func TagMatches(tag string, filter string) bool {
+	tag = CanonicalTag(tag)
+	if strings.HasSuffix(filter, "*") {
+		return strings.HasPrefix(tag, CanonicalTag(strings.TrimSuffix(filter, "*")))
+	}
+	filter = CanonicalTag(filter)
+	return tag == filter || strings.HasPrefix(tag, filter+"/")
+}
+
+// if word is in the tag, ignoring case and aliases, so dock finds docker and
+// k8s finds kubernetes, for words typed without saying they are tags
+func TagContains(tag string, word string) bool {
+	if strings.Contains(strings.ToLower(tag), strings.ToLower(word)) {
+		return true
+	}
+	return strings.Contains(CanonicalTag(tag), CanonicalTag(word))
+}
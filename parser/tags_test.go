@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetTagsFromDescription(t *testing.T) {
+	tests := []struct {
+		description string
+		want        []string
+	}{
+		{"no tags", []string{}},
+		{"@docker", []string{"docker"}},
+		{"runs it @docker @env/prod", []string{"docker", "env/prod"}},
+		{"needs @node.js.", []string{"node.js"}},
+		{"@c++ and @a_b-c", []string{"c++", "a_b-c"}},
+		{"@svc/payments/api, done", []string{"svc/payments/api"}},
+		{"@ alone", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := getTagsFromDescription(test.description); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("getTagsFromDescription(%q) = %v, want %v", test.description, got, test.want)
+		}
+	}
+}
+
+func TestIsValidTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want bool
+	}{
+		{"docker", true},
+		{"env/prod", true},
+		{"node.js", true},
+		{"env/", false},
+		{"/prod", false},
+		{"ends.", false},
+		{"two words", false},
+		{"env*", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := IsValidTag(test.tag); got != test.want {
+			t.Errorf("IsValidTag(%q) = %v, want %v", test.tag, got, test.want)
+		}
+	}
+}
+
+func TestTagMatches(t *testing.T) {
+	SetTagAliases(map[string][]string{"kubernetes": {"k8s", "kube"}})
+	defer SetTagAliases(nil)
+
+	tests := []struct {
+		tag    string
+		filter string
+		want   bool
+	}{
+		{"docker", "docker", true},
+		{"Docker", "docker", true},
+		{"env/prod", "env", true},
+		{"env/prod", "env/prod", true},
+		{"env", "env/prod", false},
+		{"environment", "env", false},
+		{"environment", "env*", true},
+		{"k8s", "kubernetes", true},
+		{"kubernetes", "kube", true},
+		{"k8s/pods", "kubernetes", true},
+		{"dock", "docker", false},
+	}
+
+	for _, test := range tests {
+		if got := TagMatches(test.tag, test.filter); got != test.want {
+			t.Errorf("TagMatches(%q, %q) = %v, want %v", test.tag, test.filter, got, test.want)
+		}
+	}
+}
+
+func TestTagContains(t *testing.T) {
+	SetTagAliases(map[string][]string{"kubernetes": {"k8s"}})
+	defer SetTagAliases(nil)
+
+	tests := []struct {
+		tag  string
+		word string
+		want bool
+	}{
+		{"docker", "dock", true},
+		{"Docker", "DOCK", true},
+		{"kubernetes", "k8s", true},
+		{"env/prod", "prod", true},
+		{"docker", "podman", false},
+	}
+
+	for _, test := range tests {
+		if got := TagContains(test.tag, test.word); got != test.want {
+			t.Errorf("TagContains(%q, %q) = %v, want %v", test.tag, test.word, got, test.want)
+		}
+	}
+}
+
+func TestTagRest(t *testing.T) {
+	SetTagAliases(map[string][]string{"kubernetes": {"k8s"}})
+	defer SetTagAliases(nil)
+
+	tests := []struct {
+		tag    string
+		filter string
+		rest   string
+		ok     bool
+	}{
+		{"env", "env", "", true},
+		{"env/prod", "env", "/prod", true},
+		{"Env/Prod", "env", "/Prod", true},
+		{"k8s/pods", "kubernetes", "/pods", true},
+		{"env/prod", "env/prod", "", true},
+		{"environment", "env", "", false},
+		{"env/prod", "env*", "", false},
+	}
+
+	for _, test := range tests {
+		rest, ok := TagRest(test.tag, test.filter)
+		if rest != test.rest || ok != test.ok {
+			t.Errorf("TagRest(%q, %q) = %q, %v, want %q, %v", test.tag, test.filter, rest, ok, test.rest, test.ok)
+		}
+	}
+}
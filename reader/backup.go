@@ -0,0 +1,183 @@
+package reader
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sebastianxyzsss/tardigrade/globals"
+)
+
+var TardiBackups string = TardiContentDir + "/backups"
+
+const backupTimeFormat = "20060102-150405.000"
+
+// a copy of a file as it was before tardigrade rewrote it
+type Backup struct {
+	Name     string
+	Original string
+	Time     time.Time
+}
+
+func GetBackupsPath() (*string, error) {
+	userDirName, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	backups := userDirName + "/" + TardiBackups
+	return &backups, nil
+}
+
+// the original path is kept in the backup name, so any file can be restored
+func backupName(original string, t time.Time) string {
+	return t.Format(backupTimeFormat) + "_" + url.QueryEscape(original)
+}
+
+func parseBackupName(name string) (*Backup, error) {
+	timeStr, escaped, found := strings.Cut(name, "_")
+	if !found {
+		return nil, fmt.Errorf("not a backup: %s", name)
+	}
+	t, err := time.ParseInLocation(backupTimeFormat, timeStr, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	original, err := url.QueryUnescape(escaped)
+	if err != nil {
+		return nil, err
+	}
+	return &Backup{Name: name, Original: original, Time: t}, nil
+}
+
+// copies fileName to the backups directory if its content is about to change,
+// keeping only the newest globals.BackupCount copies of it
+func backupFile(fileName string, newContent string) error {
+	if globals.BackupCount <= 0 || DoesFileNotExist(fileName) {
+		return nil
+	}
+
+	current, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	if string(current) == newContent {
+		return nil
+	}
+
+	backupsPath, err := GetBackupsPath()
+	if err != nil {
+		return err
+	}
+	original, err := filepath.Abs(fileName)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(original, *backupsPath+"/") {
+		return nil
+	}
+
+	err = os.MkdirAll(*backupsPath, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(*backupsPath, backupName(original, time.Now())), current, 0600)
+	if err != nil {
+		return err
+	}
+	ll.Debug().Msg("backup made of " + original)
+
+	return rotateBackups(original)
+}
+
+func rotateBackups(original string) error {
+	return removeBackups(original, globals.BackupCount)
+}
+
+// removes the backups of original but the newest keep ones
+func removeBackups(original string, keep int) error {
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	kept := 0
+	for _, backup := range backups {
+		if backup.Original != original {
+			continue
+		}
+		kept++
+		if kept > keep {
+			ll.Debug().Msg("removing old backup " + backup.Name)
+			err = os.Remove(backupFilePath(backup))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func backupFilePath(backup Backup) string {
+	backupsPath, err := GetBackupsPath()
+	if err != nil {
+		return backup.Name
+	}
+	return filepath.Join(*backupsPath, backup.Name)
+}
+
+// all the backups, the newest first
+func ListBackups() ([]Backup, error) {
+	backupsPath, err := GetBackupsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]Backup, 0)
+
+	files, err := os.ReadDir(*backupsPath)
+	if os.IsNotExist(err) {
+		return backups, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		backup, err := parseBackupName(file.Name())
+		if err != nil {
+			ll.Debug().Msg("skipping " + file.Name() + ": " + err.Error())
+			continue
+		}
+		backups = append(backups, *backup)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// RemoveBackups removes every backup of fileName
+func RemoveBackups(fileName string) error {
+	original, err := filepath.Abs(fileName)
+	if err != nil {
+		return err
+	}
+	return removeBackups(original, 0)
+}
+
+// puts the content of the backup back in its original file, the content
+// being replaced is backed up first so a wrong pick can be undone
+func RestoreBackup(backup Backup) error {
+	content := GetFileAsString(backupFilePath(backup))
+	if content == nil {
+		return fmt.Errorf("unable to read backup %s", backup.Name)
+	}
+	return WithFileLock(backup.Original, func() error {
+		return writeAtomically(backup.Original, *content)
+	})
+}
@@ -0,0 +1,115 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sebastianxyzsss/tardigrade/globals"
+)
+
+func TestBackups(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	count := globals.BackupCount
+	globals.BackupCount = 2
+	defer func() { globals.BackupCount = count }()
+
+	fileName := filepath.Join(home, TardiContent)
+	for _, content := range []string{"v1", "v2", "v2", "v3", "v4"} {
+		// backups are named by the millisecond
+		time.Sleep(2 * time.Millisecond)
+		err := WriteToFile(fileName, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the same content again makes no backup, only the newest two are kept
+	backups := backupContents(t, fileName)
+	want := []string{"v3", "v2"}
+	if !equal(backups, want) {
+		t.Fatalf("backups %v, want %v", backups, want)
+	}
+
+	all, _ := ListBackups()
+	time.Sleep(2 * time.Millisecond)
+	err := RestoreBackup(all[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(fileName)
+	if string(got) != "v2" {
+		t.Errorf("restored content %q, want v2", got)
+	}
+	// the content replaced by the restore is backed up
+	backups = backupContents(t, fileName)
+	want = []string{"v4", "v3"}
+	if !equal(backups, want) {
+		t.Errorf("backups after the restore %v, want %v", backups, want)
+	}
+
+	err = RemoveBackups(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backups = backupContents(t, fileName); len(backups) != 0 {
+		t.Errorf("backups left after removing them: %v", backups)
+	}
+}
+
+func TestParseBackupName(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		ok       bool
+	}{
+		{"20261019-130754.123_%2Fhome%2Fa%2Ffile_1.yml", "/home/a/file_1.yml", true},
+		{"notabackup", "", false},
+		{"2026_%2Fa", "", false},
+	}
+
+	for _, test := range tests {
+		backup, err := parseBackupName(test.name)
+		if (err == nil) != test.ok {
+			t.Errorf("parseBackupName(%q) error %v, want ok %v", test.name, err, test.ok)
+			continue
+		}
+		if test.ok && backup.Original != test.original {
+			t.Errorf("parseBackupName(%q) original %q, want %q", test.name, backup.Original, test.original)
+		}
+	}
+}
+
+// the contents of the backups of fileName, newest first
+func backupContents(t *testing.T, fileName string) []string {
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make([]string, 0)
+	for _, backup := range backups {
+		if backup.Original != fileName {
+			continue
+		}
+		content, err := os.ReadFile(backupFilePath(backup))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(content))
+	}
+	return contents
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
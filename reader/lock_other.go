@@ -0,0 +1,14 @@
+//go:build !unix
+
+package reader
+
+import "os"
+
+// there is no advisory locking here, writes are still atomic
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
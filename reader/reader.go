@@ -6,10 +6,12 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/sebastianxyzsss/tardigrade/globals"
 	"github.com/sebastianxyzsss/tardigrade/logger"
+	"github.com/sebastianxyzsss/tardigrade/redacter"
 	"gopkg.in/yaml.v2"
 )
 
@@ -21,6 +23,8 @@ var TardiContent string = TardiContentDir + "/tardicontent.yml"
 
 var TardiHistory string = TardiContentDir + "/tardihistory.yml"
 
+var TardiFavorites string = TardiContentDir + "/tardifavorites.yml"
+
 func Unmarshall(mapStr string) *map[interface{}]interface{} {
 	m := make(map[interface{}]interface{})
 	err := yaml.Unmarshal([]byte(mapStr), &m)
@@ -57,7 +61,7 @@ func GetHttpRequestAsString(urlString string) *string {
 	url := strings.Replace(urlString, "/https://", "https://", -1)
 	url = strings.Replace(url, "/http://", "http://", -1)
 
-	ll.Debug().Msg("url with content: " + url)
+	ll.Debug().Msg("url with content: " + redacter.Redact(url))
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -93,6 +97,7 @@ func getLocalContent() *map[interface{}]interface{} {
 	} else {
 		ll.Debug().Msg("getting local content")
 	}
+	indexSources(TardiContent, *yamlContent)
 	yamlAsMap := Unmarshall(*yamlContent)
 	return yamlAsMap
 }
@@ -112,6 +117,7 @@ func getUserHomeFileContent(fileName string) *map[interface{}]interface{} {
 	} else {
 		ll.Debug().Msg("getting content " + fileName)
 	}
+	indexSources(userDirName+"/"+fileName, *yamlContent)
 	yamlAsMap := Unmarshall(*yamlContent)
 	return yamlAsMap
 }
@@ -130,18 +136,14 @@ func GetHistoryPath() (*string, error) {
 	return &userTardiHistory, nil
 }
 
-func getHistoryContent() *map[interface{}]interface{} {
-	userTardiHistory, err := GetHistoryPath()
+func GetFavoritesPath() (*string, error) {
+	userDirName, err := os.UserHomeDir()
 	if err != nil {
-		ll.Debug().Msg("unable to get history path: " + err.Error())
-		return nil
-	}
-	ll.Debug().Msg("userTardiHistory: " + *userTardiHistory)
-	if DoesFileNotExist(*userTardiHistory) {
-		ll.Debug().Msg("history does not exist, so creating")
-		WriteToFile(*userTardiHistory, firstInHistory())
+		ll.Debug().Msg("unable to get user home dir: " + err.Error())
+		return nil, err
 	}
-	return getUserHomeFileContent(TardiHistory)
+	userTardiFavorites := userDirName + "/" + TardiFavorites
+	return &userTardiFavorites, nil
 }
 
 func appendFileListContent(m *map[interface{}]interface{}, filesToRead []string) *map[interface{}]interface{} {
@@ -151,35 +153,29 @@ func appendFileListContent(m *map[interface{}]interface{}, filesToRead []string)
 
 	for _, fileToRead := range filesToRead {
 
-		ll.Debug().Msg("reading file (or url): " + fileToRead)
+		ll.Debug().Msg("reading file (or url): " + redacter.Redact(fileToRead))
 
 		var yamlContent *string = nil
 
-		if strings.Contains(fileToRead, "/http") {
+		if strings.Contains(fileToRead, "http://") || strings.Contains(fileToRead, "https://") {
 			yamlContent = GetHttpRequestAsString(fileToRead)
 		} else {
 			yamlContent = GetFileAsString(fileToRead)
 		}
 
 		if yamlContent == nil {
-			ll.Info().Msg("unable to get file content for: " + fileToRead)
+			ll.Info().Msg("unable to get file content for: " + redacter.Redact(fileToRead))
 			continue
 		} else {
-			ll.Debug().Msg("found content for: " + fileToRead)
+			ll.Debug().Msg("found content for: " + redacter.Redact(fileToRead))
 		}
+		indexSources(redacter.Redact(fileToRead), *yamlContent)
 		yamlAsMap := Unmarshall(*yamlContent)
-		m = appendMap(m, yamlAsMap)
+		m = AppendMap(m, yamlAsMap)
 	}
 	return m
 }
 
-func firstInHistory() string {
-	var historyData = `history:
-  - pwd
-`
-	return historyData
-}
-
 func getDummyStr(prefix string) string {
 	var dummyData = `group10:
   - ls -la
@@ -208,27 +204,6 @@ func getUserDirName() string {
 	return userDirName
 }
 
-func WriteToFile(fileName string, content string) {
-
-	f, err := os.Create(fileName)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	_, err = f.WriteString(content)
-	if err != nil {
-		fmt.Println(err)
-		f.Close()
-		return
-	}
-	ll.Debug().Msg("bytes written successfully to " + fileName)
-	err = f.Close()
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-}
-
 func CreateNewUserContentFile() {
 	userDirName := getUserDirName()
 
@@ -282,26 +257,23 @@ func CreateNewContentFile(tardiContentDirName string, tardiContentFileName strin
 		return
 	}
 
-	newfile, err := os.Create(tardiContentFileName)
-	defer newfile.Close()
-	if err != nil {
-		ll.Error().Msg("unable to create file: " + err.Error())
-		return
-	}
-
 	var dummyData = getDummyStr(dummyGroupPrefix)
 
-	_, err2 := newfile.WriteString(dummyData)
-
-	if err2 != nil {
+	err := WriteToFile(tardiContentFileName, dummyData)
+	if err != nil {
 		ll.Error().Msg("unable to write to file: " + err.Error())
 		return
 	}
 
+	err = CommitChange("content: created "+tardiContentFileName, tardiContentFileName)
+	if err != nil {
+		ll.Warn().Msg("unable to version the change: " + err.Error())
+	}
+
 	ll.Debug().Msg("created file")
 }
 
-func appendMap(m1 *map[interface{}]interface{}, m2 *map[interface{}]interface{}) *map[interface{}]interface{} {
+func AppendMap(m1 *map[interface{}]interface{}, m2 *map[interface{}]interface{}) *map[interface{}]interface{} {
 	if m1 == nil && m2 == nil {
 		return nil
 	}
@@ -336,11 +308,7 @@ func GetRawMapContent(filesToRead []string) *map[interface{}]interface{} {
 
 	localYamlAsMap := getLocalContent()
 
-	historyAsMap := getHistoryContent()
-
-	yamlAsMap = appendMap(yamlAsMap, localYamlAsMap)
-
-	yamlAsMap = appendMap(yamlAsMap, historyAsMap)
+	yamlAsMap = AppendMap(yamlAsMap, localYamlAsMap)
 
 	if globals.FilterAction == globals.FilterFiles || globals.FilterAction == globals.FilterNone {
 		yamlAsMap = appendFileListContent(yamlAsMap, filesToRead)
@@ -353,3 +321,21 @@ func GetRawMapContent(filesToRead []string) *map[interface{}]interface{} {
 
 	return yamlAsMap
 }
+
+// the content files tardigrade can rewrite, the one in the user home and the
+// local one, when they exist; files given on the command line are left alone
+func WritableContentFiles() []string {
+	files := make([]string, 0)
+	candidates := []string{getUserDirName() + "/" + TardiContent, TardiContent}
+	for _, candidate := range candidates {
+		absolute, err := filepath.Abs(candidate)
+		if err != nil || DoesFileNotExist(absolute) {
+			continue
+		}
+		if len(files) > 0 && files[0] == absolute {
+			continue
+		}
+		files = append(files, absolute)
+	}
+	return files
+}
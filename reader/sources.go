@@ -0,0 +1,75 @@
+package reader
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// where each group and command was declared, as file:line, by file and then
+// by the path of contents from the top group down, the same path as
+// parser.ElementPath
+var sources = make(map[string]map[string]string)
+
+// the file each top group was taken from, a later file replaces the whole
+// group, as AppendMap does
+var topGroupFiles = make(map[string]string)
+
+// Source is the file:line where the element of path, under the top group
+// top, was declared; the top group is given as it is since its name may have
+// slashes too
+func Source(top string, path string) string {
+	file, ok := topGroupFiles[top]
+	if !ok {
+		return ""
+	}
+	return sources[file][path]
+}
+
+// the content of a key or an item, without the comment after ^
+func sourceContent(value string) string {
+	return strings.TrimSpace(strings.Split(value, "^")[0])
+}
+
+func indexSources(fileName string, content string) {
+	var document yaml.Node
+	err := yaml.Unmarshal([]byte(content), &document)
+	if err != nil {
+		ll.Debug().Msg("unable to index sources of " + fileName + ": " + err.Error())
+		return
+	}
+	sources[fileName] = make(map[string]string)
+	for _, node := range document.Content {
+		indexNode(fileName, node, "")
+	}
+}
+
+func indexNode(fileName string, node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			childPath := joinSourcePath(path, sourceContent(key.Value))
+			if path == "" {
+				topGroupFiles[childPath] = fileName
+			}
+			sources[fileName][childPath] = fmt.Sprintf("%s:%d", fileName, key.Line)
+			indexNode(fileName, node.Content[i+1], childPath)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				continue
+			}
+			sources[fileName][joinSourcePath(path, sourceContent(item.Value))] = fmt.Sprintf("%s:%d", fileName, item.Line)
+		}
+	}
+}
+
+func joinSourcePath(path string, content string) string {
+	if path == "" {
+		return content
+	}
+	return path + "/" + content
+}
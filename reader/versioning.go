@@ -0,0 +1,143 @@
+package reader
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sebastianxyzsss/tardigrade/globals"
+)
+
+// files in the tardigrade dir that are not worth versioning
+var gitIgnored = `backups/
+*.lock
+.*.tmp*
+tardilog.log
+`
+
+func GetTardiDirPath() (*string, error) {
+	userDirName, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	tardiDir := userDirName + "/" + TardiContentDir
+	return &tardiDir, nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func initTardiRepo(dir string) error {
+	if !DoesFileNotExist(filepath.Join(dir, ".git")) {
+		return nil
+	}
+	ll.Debug().Msg("initialising git repository in " + dir)
+	_, err := runGit(dir, "init", "--quiet")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(gitIgnored), 0664)
+}
+
+func isHistory(abs string) bool {
+	userTardiHistory, err := GetHistoryPath()
+	if err != nil {
+		return false
+	}
+	history, err := filepath.Abs(*userTardiHistory)
+	return err == nil && history == abs
+}
+
+// the files of the tardigrade dir among fileNames, relative to it, the
+// history only with githistory in the settings: it changes with every command
+// and a secret saved before it was redacted can not be scrubbed out of git
+func tardiDirFiles(tardiDir string, fileNames []string) []string {
+	files := make([]string, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		abs, err := filepath.Abs(fileName)
+		if err != nil || (isHistory(abs) && !globals.GitHistory) {
+			continue
+		}
+		rel, err := filepath.Rel(tardiDir, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		files = append(files, rel)
+	}
+	return files
+}
+
+// CommitChange commits the files tardigrade just wrote, when git versioning
+// is turned on in the settings; other changes in the tardigrade dir, and files
+// outside of it, are left alone
+func CommitChange(message string, fileNames ...string) error {
+	if !globals.GitVersioning {
+		return nil
+	}
+
+	tardiDir, err := GetTardiDirPath()
+	if err != nil {
+		return err
+	}
+	files := tardiDirFiles(*tardiDir, fileNames)
+	if len(files) == 0 {
+		ll.Debug().Msg("nothing to commit in " + *tardiDir)
+		return nil
+	}
+
+	return WithFileLock(filepath.Join(*tardiDir, ".git"), func() error {
+		err := initTardiRepo(*tardiDir)
+		if err != nil {
+			return err
+		}
+
+		_, err = runGit(*tardiDir, append([]string{"add", "--"}, files...)...)
+		if err != nil {
+			return err
+		}
+
+		status, err := runGit(*tardiDir, append([]string{"status", "--porcelain", "--"}, files...)...)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(status) == "" {
+			ll.Debug().Msg("nothing to commit")
+			return nil
+		}
+
+		args := append([]string{"commit", "--quiet", "-m", message, "--"}, files...)
+		// commits still work for users without a git identity
+		if email, _ := runGit(*tardiDir, "config", "user.email"); strings.TrimSpace(email) == "" {
+			args = append([]string{"-c", "user.name=tardigrade", "-c", "user.email=tardigrade@localhost"}, args...)
+		}
+		_, err = runGit(*tardiDir, args...)
+		return err
+	})
+}
+
+// the change log of the tardigrade dir, optionally only of one of its files
+func ChangeLog(fileName string) (string, error) {
+	tardiDir, err := GetTardiDirPath()
+	if err != nil {
+		return "", err
+	}
+	if DoesFileNotExist(filepath.Join(*tardiDir, ".git")) {
+		return "", fmt.Errorf("%s is not versioned yet, turn on gitversioning in the settings", *tardiDir)
+	}
+	args := []string{"log", "--date=format:%Y-%m-%d %H:%M", "--format=%h  %ad  %s"}
+	if fileName != "" {
+		args = append(args, "--", fileName)
+	}
+	return runGit(*tardiDir, args...)
+}
@@ -0,0 +1,142 @@
+package reader
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+var TardiLocks string = TardiContentDir + "/locks"
+
+// the file a path stands for, following symlinks, like a content file kept in
+// a dotfiles repository; a file that does not exist yet is its own target
+func writeTarget(fileName string) (string, error) {
+	target, err := filepath.EvalSymlinks(fileName)
+	if os.IsNotExist(err) {
+		return filepath.Abs(fileName)
+	}
+	return target, err
+}
+
+// the lock is taken on a separate file in the tardigrade directory, named
+// after the real path, the file itself is replaced on every write
+func lockPath(fileName string) (string, error) {
+	target, err := writeTarget(fileName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(getUserDirName(), TardiLocks, url.QueryEscape(target)+".lock"), nil
+}
+
+// runs fn while holding an advisory lock for fileName, so other tardigrade
+// processes wait before touching the same file
+func WithFileLock(fileName string, fn func() error) error {
+	lockName, err := lockPath(fileName)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(lockName), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(lockName, os.O_CREATE|os.O_RDWR, 0664)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	err = lockFile(lock)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+
+	return fn()
+}
+
+// backs fileName up and replaces its content
+func writeAtomically(fileName string, content string) error {
+	err := backupFile(fileName, content)
+	if err != nil {
+		return fmt.Errorf("unable to backup %s: %w", fileName, err)
+	}
+	return replaceFile(fileName, content)
+}
+
+// writes content to a temporary file next to the file fileName stands for
+// and renames it over that file, so readers see either the old or the new
+// content, never a part, and symlinks to it stay in place
+func replaceFile(fileName string, content string) error {
+	target, err := writeTarget(fileName)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(target), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	fileName = target
+
+	mode := os.FileMode(0664)
+	if info, err := os.Stat(fileName); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.WriteString(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, mode)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, fileName)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	ll.Debug().Msg("bytes written successfully to " + fileName)
+	return nil
+}
+
+// WriteToFile replaces the content of fileName atomically and under its lock
+func WriteToFile(fileName string, content string) error {
+	return WithFileLock(fileName, func() error {
+		return writeAtomically(fileName, content)
+	})
+}
+
+// UpdateFile reads fileName and writes back what update returns, all under
+// the lock, so changes made by another process in between are not lost.
+// current is nil when the file does not exist yet, a file that cannot be
+// read is left untouched.
+func UpdateFile(fileName string, update func(current *string) (string, error)) error {
+	return WithFileLock(fileName, func() error {
+		var current *string
+		data, err := os.ReadFile(fileName)
+		if err == nil {
+			text := string(data)
+			current = &text
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		content, err := update(current)
+		if err != nil {
+			return err
+		}
+		return writeAtomically(fileName, content)
+	})
+}
@@ -0,0 +1,140 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWriteToFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		path    string
+		old     string
+		content string
+		mode    os.FileMode
+	}{
+		{"new file", "new.yml", "", "new", 0664},
+		{"existing file keeps its mode", "existing.yml", "old", "replaced", 0600},
+		{"missing dir", "missing/sub/file.yml", "", "deep", 0664},
+	}
+
+	for _, test := range tests {
+		fileName := filepath.Join(dir, test.path)
+		if test.old != "" {
+			os.WriteFile(fileName, []byte(test.old), test.mode)
+		}
+
+		err := WriteToFile(fileName, test.content)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		got, err := os.ReadFile(fileName)
+		if err != nil || string(got) != test.content {
+			t.Errorf("%s: content %q, %v, want %q", test.name, got, err, test.content)
+		}
+		info, err := os.Stat(fileName)
+		if err != nil || info.Mode().Perm() != test.mode {
+			t.Errorf("%s: mode %v, want %v", test.name, info.Mode().Perm(), test.mode)
+		}
+		leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(fileName), ".*.tmp*"))
+		if len(leftovers) > 0 {
+			t.Errorf("%s: temporary files left: %v", test.name, leftovers)
+		}
+	}
+}
+
+func TestWriteToFileThroughSymlink(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "tardicontent.yml")
+	link := filepath.Join(dir, "tardicontent.yml")
+	os.MkdirAll(filepath.Dir(target), os.ModePerm)
+	os.WriteFile(target, []byte("old"), 0664)
+	err := os.Symlink(target, link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteToFile(link, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("the symlink was replaced by a file")
+	}
+	got, _ := os.ReadFile(target)
+	if string(got) != "new" {
+		t.Errorf("target content %q, want %q", got, "new")
+	}
+}
+
+func TestUpdateFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	fileName := filepath.Join(dir, "counter")
+	err := UpdateFile(fileName, func(current *string) (string, error) {
+		if current != nil {
+			t.Errorf("current is %q for a file that does not exist", *current)
+		}
+		return "0", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every update sees the one before it, none is lost
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := UpdateFile(fileName, func(current *string) (string, error) {
+				n, err := strconv.Atoi(*current)
+				return strconv.Itoa(n + 1), err
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := os.ReadFile(fileName)
+	if string(got) != "20" {
+		t.Errorf("counter is %s after 20 updates", got)
+	}
+}
+
+func TestUpdateFileLeavesUnreadableFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	// a directory can not be read as a file
+	fileName := filepath.Join(dir, "history")
+	os.MkdirAll(filepath.Join(fileName, "keep"), os.ModePerm)
+
+	called := false
+	err := UpdateFile(fileName, func(current *string) (string, error) {
+		called = true
+		return "new", nil
+	})
+	if err == nil {
+		t.Error("UpdateFile of a directory did not fail")
+	}
+	if called {
+		t.Error("the update was called for a file that could not be read")
+	}
+	if _, err := os.Stat(filepath.Join(fileName, "keep")); err != nil {
+		t.Errorf("the directory was replaced: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package redacter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sebastianxyzsss/tardigrade/logger"
+)
+
+var ll = logger.SetupLog()
+
+// a redacted value is replaced with a secret placeholder, so running the
+// command again from the history asks for the value instead of running the
+// marker, the second one in a command is <redacted2!secret> and so on
+const markerName = "redacted"
+
+func marker(n int) string {
+	if n == 1 {
+		return "<" + markerName + "!secret>"
+	}
+	return fmt.Sprintf("<%s%d!secret>", markerName, n)
+}
+
+// used when the settings have no redactions section, when a pattern has a
+// capture group the first group is kept and the rest of the match is redacted
+var DefaultPatterns = []string{
+	`(?i)(--(?:token|password|passwd|secret|api-key|apikey)[= ])\S+`,
+	`(?i)((?:token|password|passwd|pwd|secret|api_key|apikey)=)[^\s&'"]+`,
+	`(?i)(Authorization:\s*(?:Bearer|Basic|Token)\s+)[^\s'"]+`,
+	`(?i)(Bearer\s+)[A-Za-z0-9\-._~+/]+=*`,
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)(aws_secret_access_key\s*[=:]\s*)\S+`,
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`(://[^/\s:@]+:)[^@\s/]+(@)`,
+}
+
+var patterns []*regexp.Regexp = compileAll(DefaultPatterns)
+
+func compileAll(strs []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(strs))
+	for _, str := range strs {
+		compiled = append(compiled, regexp.MustCompile(str))
+	}
+	return compiled
+}
+
+// replaces the active patterns, nothing changes if any of them is invalid
+func SetPatterns(strs []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(strs))
+	for _, str := range strs {
+		re, err := regexp.Compile(str)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, re)
+	}
+	patterns = compiled
+	ll.Debug().Int("patterns", len(patterns)).Msg("redaction patterns set")
+	return nil
+}
+
+// Redact hides every part of str that matches one of the patterns
+func Redact(str string) string {
+	n := 0
+	next := func() string {
+		// skip the markers left by an earlier redaction of str
+		for {
+			n++
+			if !strings.Contains(str, marker(n)) {
+				return marker(n)
+			}
+		}
+	}
+	for _, re := range patterns {
+		str = redactWith(re, str, next)
+	}
+	return str
+}
+
+func redactWith(re *regexp.Regexp, str string, next func() string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(str, -1) {
+		b.WriteString(str[last:loc[0]])
+		if isPlaceholder(str, loc) {
+			// nothing to hide, the value is asked for on every run
+			b.WriteString(str[loc[0]:loc[1]])
+		} else if len(loc) >= 4 && loc[2] >= 0 {
+			// keep the first group, e.g. the "--token=" part
+			b.WriteString(str[loc[2]:loc[3]])
+			b.WriteString(next())
+			// keep a closing group, e.g. the "@" of user:pass@host
+			if len(loc) >= 6 && loc[4] >= 0 {
+				b.WriteString(str[loc[4]:loc[5]])
+			}
+		} else {
+			b.WriteString(next())
+		}
+		last = loc[1]
+	}
+	b.WriteString(str[last:])
+	return b.String()
+}
+
+var placeholderRegex = regexp.MustCompile(`^<[^<>\s]+>$`)
+
+func isPlaceholder(str string, loc []int) bool {
+	start, end := loc[0], loc[1]
+	if len(loc) >= 4 && loc[2] >= 0 {
+		start = loc[3]
+	}
+	if len(loc) >= 6 && loc[4] >= 0 {
+		end = loc[4]
+	}
+	return placeholderRegex.MatchString(str[start:end])
+}
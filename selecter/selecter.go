@@ -2,144 +2,189 @@ package selecter
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/charmbracelet/gum/style"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
 	"github.com/muesli/termenv"
+	"github.com/sebastianxyzsss/tardigrade/action"
+	"github.com/sebastianxyzsss/tardigrade/favorites"
 	"github.com/sebastianxyzsss/tardigrade/filterer"
 	"github.com/sebastianxyzsss/tardigrade/globals"
+	"github.com/sebastianxyzsss/tardigrade/history"
 	"github.com/sebastianxyzsss/tardigrade/logger"
 	"github.com/sebastianxyzsss/tardigrade/parser"
-	"github.com/sebastianxyzsss/tardigrade/reader"
+	"github.com/sebastianxyzsss/tardigrade/redacter"
 	"github.com/sebastianxyzsss/tardigrade/utils"
 )
 
 var ll = logger.SetupLog()
 
-func createOptions(settings *globals.Settings) *filterer.Options {
+func createOptions(settings *globals.Settings, userHistory *history.History) *filterer.Options {
 	ll.Debug().Msg("createOptions")
 
 	filterOpts := filterer.Options{}
 
+	t := createTheme(settings)
+
 	filterOpts.Indicator = "•"
-	filterOpts.IndicatorStyle = style.Styles{
-		Foreground: settings.IndicatorStyle,
-	}
+	filterOpts.IndicatorStyle = t[partIndicator]
 
-	filterOpts.Header = "}}}}@ tardigrade"
-	filterOpts.HeaderStyle = style.Styles{
-		Foreground: "33",
-	}
+	filterOpts.Header = "}}}}@"
+	filterOpts.HeaderStyle = t[partHeader]
 
 	filterOpts.Prompt = "> "
 	filterOpts.Placeholder = "..."
 
-	filterOpts.Limit = 1
-
 	if globals.FlatParse {
 		settings.Height += 4
 	}
 
 	filterOpts.Height = settings.Height
 
-	filterOpts.MatchStyle = style.Styles{
-		Foreground: "201",
-	}
+	filterOpts.MatchStyle = t[partMatch]
+	filterOpts.PromptStyle = t[partPrompt]
+	filterOpts.TextStyle = t[partText]
 
-	filterOpts.PromptStyle = style.Styles{
-		Foreground: "23",
+	filterOpts.Ranking = filterer.RankBlended
+	if settings.Ranking != "" {
+		if filterer.IsValidRanking(settings.Ranking) {
+			filterOpts.Ranking = settings.Ranking
+		} else {
+			ll.Warn().Msg("unknown ranking " + settings.Ranking + ", using " + filterer.RankBlended)
+		}
 	}
 
-	return &filterOpts
-}
-
-func finalElementApply(rootElement *parser.Element, element *parser.Element) {
-	ll.Debug().Msg("final element:" + element.String())
+	if userHistory != nil {
+		frecency := userHistory.Frecency(time.Now())
+		filterOpts.Frecency = frecency.Score
+		filterOpts.Usage = userHistory.Usage
+	}
 
-	if element.Parent != nil {
-		if parser.DoesElementHaveCommandTag(element.Parent) {
-			content := utils.ReplaceContentWithChoices(element.Parent.Content, element.Content)
-			element.Content = content
+	filterOpts.Layout = filterer.LayoutList
+	if settings.Layout != "" {
+		if filterer.IsValidLayout(settings.Layout) {
+			filterOpts.Layout = settings.Layout
+		} else {
+			ll.Warn().Msg("unknown layout " + settings.Layout + ", using " + filterer.LayoutList)
 		}
 	}
 
-	historyElement := parser.HistoryTemp
+	// the filter looks in the commands, descriptions and tags, tag mode only
+	// in the tags
+	filterOpts.Flat = globals.FlatParse
+	filterOpts.Scope = filterer.ScopeEverything
+	if globals.FilterAction == globals.FilterTags {
+		filterOpts.Scope = filterer.ScopeTags
+	}
 
-	childrenSorted := []*parser.Element{}
-	childrenSorted = append(childrenSorted, element)
+	keys, err := filterer.NewKeyMap(settings.KeyPreset, settings.Keys)
+	if err != nil {
+		ll.Warn().Err(err).Msg("invalid keys, using the default ones")
+		keys, _ = filterer.NewKeyMap(filterer.KeysDefault, nil)
+	}
+	filterOpts.Keys = keys
 
-	for _, historyChild := range historyElement.ChildrenSorted {
-		if historyChild.Content != element.Content {
-			childrenSorted = append(childrenSorted, historyChild)
-		}
-		if len(childrenSorted) >= globals.HistorySize {
-			break
+	filterOpts.Preview = filterer.PreviewOff
+	if settings.Preview != "" {
+		if filterer.IsValidPreview(settings.Preview) {
+			filterOpts.Preview = settings.Preview
+		} else {
+			ll.Warn().Msg("unknown preview " + settings.Preview + ", using " + filterer.PreviewOff)
 		}
 	}
-	historyElement.ChildrenSorted = childrenSorted
 
-	historyMap := parser.SimpleElementToMap(historyElement)
+	return &filterOpts
+}
+
+func finalElementApply(filterOpts *filterer.Options, element *parser.Element, mode string) error {
+	ll.Debug().Msg("final element:" + redacter.Redact(element.String()))
+
+	content := parser.CommandString(element)
+
+	placeholders := utils.FindPlaceholders(content)
 
-	yaml, err := reader.Marshall(&historyMap)
+	values, err := filterOpts.PromptPlaceholders(placeholders)
 	if err != nil {
-		log.Print(err)
-		return
+		return err
 	}
 
-	userTardiHistory, err := reader.GetHistoryPath()
+	toRun := utils.ReplacePlaceholders(content, values)
+	if !isatty.IsTerminal(os.Stdout.Fd()) {
+		toRun = filterer.Strip(toRun)
+	}
+	action.Get(mode).Execute(toRun)
+
+	// secret values are only used for running, history keeps the placeholder
+	entry := history.NewEntry(redacter.Redact(content), element, mode)
+	err = history.Record(entry)
 	if err != nil {
-		ll.Debug().Msg("unable to get history path: " + err.Error())
-		return
+		ll.Error().Err(err).Msg("unable to save the history")
 	}
 
-	reader.WriteToFile(*userTardiHistory, *yaml)
+	return nil
+}
+
+// the command opening the editor at the file:line source, the wrapper runs
+// it like any other command
+func editCommand(source string) string {
+	file, line := source, ""
+	if i := strings.LastIndex(source, ":"); i > 0 {
+		file, line = source[:i], source[i+1:]
+	}
+	if _, err := os.Stat(file); err != nil {
+		ll.Warn().Msg(file + " can not be edited")
+		return "echo '** the file can not be edited **'"
+	}
+	return "${EDITOR:-vi} +" + line + " '" + strings.ReplaceAll(file, "'", `'\''`) + "'"
+}
+
+// stars and unstars elements, keeping the favorites group of the root up to
+// date
+func favoriteOptions(filterOpts *filterer.Options, rootElement *parser.Element, userFavorites *favorites.Favorites) {
+	filterOpts.IsFavorite = userFavorites.Has
+	filterOpts.Favorite = func(element *parser.Element) (bool, error) {
+		saved, starred, err := favorites.Toggle(element)
+		if err != nil {
+			return false, err
+		}
+		*userFavorites = *saved
+		if group, ok := rootElement.Children[parser.FavoritesGroup]; ok {
+			parser.SetVirtualChildren(group, userFavorites.Elements(parser.NewIndex(rootElement)))
+		}
+		return starred, nil
+	}
 }
 
-func Chooser(rootElement *parser.Element, settings *globals.Settings) {
+func Chooser(rootElement *parser.Element, settings *globals.Settings, userHistory *history.History, userFavorites *favorites.Favorites) {
 	ll.Debug().Msg("about to do choosing ...")
 
 	lipgloss.SetColorProfile(termenv.NewOutput(os.Stderr).Profile)
 
 	ll.Debug().Msg("filter start")
 
-	filterOpts := createOptions(settings)
+	filterOpts := createOptions(settings, userHistory)
+	if userFavorites != nil {
+		favoriteOptions(filterOpts, rootElement, userFavorites)
+	}
 
-	element := rootElement
+	chosen, chosenAction, err := filterOpts.Run(rootElement)
+	if err != nil {
+		ll.Debug().Msg("there was an interruption: " + err.Error())
+		fmt.Println("pwd")
+	} else if chosen != nil && chosenAction == filterer.ActionEdit {
+		ll.Debug().Msg("to edit:" + redacter.Redact(chosen.String()))
 
-	for {
+		fmt.Println(editCommand(chosen.Source))
+	} else if chosen != nil && chosen.IsCommand {
+		ll.Debug().Msg("chosen:" + redacter.Redact(chosen.String()))
 
-		chosen, err := filterOpts.Run(element)
+		err := finalElementApply(filterOpts, chosen, filterer.ChosenMode(chosenAction))
 		if err != nil {
-			ll.Debug().Msg("there was an interruption: " + err.Error())
-			if strings.Contains(err.Error(), "no options provided") {
-				ll.Debug().Msg("making an exception here")
-				element = element.Parent
-				continue
-			} else {
-				fmt.Println("pwd")
-				break
-			}
+			ll.Debug().Msg("command not run: " + err.Error())
 		}
-
-		if chosen != nil {
-
-			ll.Debug().Msg("chosen:" + chosen.String())
-
-			if *&chosen.IsCommand {
-				finalElementApply(rootElement, chosen)
-				break
-			}
-
-			element = chosen
-
-		} else {
-			// fmt.Println("pwd")
-			break
-		}
-
 	}
 
 	ll.Debug().Msg("filter end")
@@ -0,0 +1,134 @@
+package selecter
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/gum/style"
+	"github.com/muesli/termenv"
+	"github.com/sebastianxyzsss/tardigrade/globals"
+)
+
+// the built-in themes, auto is dark or light after the terminal background
+const (
+	ThemeAuto         = "auto"
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeMonochrome   = "monochrome"
+)
+
+// the parts of the chooser a theme styles, as they are named in the settings
+const (
+	partIndicator = "indicator"
+	partHeader    = "header"
+	partPrompt    = "prompt"
+	partText      = "text"
+	partMatch     = "match"
+)
+
+type theme map[string]style.Styles
+
+var themes = map[string]theme{
+	ThemeDark: {
+		partIndicator: {Foreground: "80"},
+		partHeader:    {Foreground: "33"},
+		partPrompt:    {Foreground: "23"},
+		partText:      {},
+		partMatch:     {Foreground: "201"},
+	},
+	ThemeLight: {
+		partIndicator: {Foreground: "30"},
+		partHeader:    {Foreground: "25"},
+		partPrompt:    {Foreground: "66"},
+		partText:      {Foreground: "235"},
+		partMatch:     {Foreground: "161"},
+	},
+	ThemeHighContrast: {
+		partIndicator: {Foreground: "11", Bold: true},
+		partHeader:    {Foreground: "14", Bold: true},
+		partPrompt:    {Foreground: "15", Bold: true},
+		partText:      {Foreground: "15"},
+		partMatch:     {Foreground: "0", Background: "11", Bold: true},
+	},
+	ThemeMonochrome: {
+		partIndicator: {Bold: true},
+		partHeader:    {Bold: true},
+		partPrompt:    {Faint: true},
+		partText:      {},
+		partMatch:     {Bold: true, Underline: true},
+	},
+}
+
+func IsValidTheme(name string) bool {
+	_, ok := themes[name]
+	return ok || name == ThemeAuto
+}
+
+// the styles of the theme of the settings, with the styles given in the theme
+// section in their place. Without colors, as NO_COLOR asks, the monochrome
+// theme is used and the colors of the settings are left out.
+func createTheme(settings *globals.Settings) theme {
+	output := termenv.NewOutput(os.Stderr)
+
+	name := settings.ThemePreset
+	if name == "" {
+		name = ThemeAuto
+	}
+	if !IsValidTheme(name) {
+		ll.Warn().Msg("unknown theme " + name + ", using " + ThemeAuto)
+		name = ThemeAuto
+	}
+	noColor := output.EnvNoColor()
+	switch {
+	case noColor:
+		name = ThemeMonochrome
+	case name == ThemeAuto && output.HasDarkBackground():
+		name = ThemeDark
+	case name == ThemeAuto:
+		name = ThemeLight
+	}
+	ll.Debug().Msg("theme: " + name)
+
+	t := make(theme, len(themes[name]))
+	for part, styles := range themes[name] {
+		t[part] = styles
+	}
+
+	// the old setting still colors the indicator
+	if settings.IndicatorStyle != "" {
+		indicator := t[partIndicator]
+		indicator.Foreground = settings.IndicatorStyle
+		t[partIndicator] = indicator
+	}
+
+	for part, styles := range settings.Theme {
+		part = strings.ToLower(part)
+		if _, ok := t[part]; !ok {
+			ll.Warn().Msg("unknown theme part " + part + ", use one of " + strings.Join(themeParts(), ", "))
+			continue
+		}
+		t[part] = styles
+	}
+
+	if noColor {
+		for part, styles := range t {
+			styles.Foreground = ""
+			styles.Background = ""
+			styles.BorderForeground = ""
+			styles.BorderBackground = ""
+			t[part] = styles
+		}
+	}
+	return t
+}
+
+func themeParts() []string {
+	parts := make([]string, 0, len(themes[ThemeDark]))
+	for part := range themes[ThemeDark] {
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	return parts
+}
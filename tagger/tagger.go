@@ -0,0 +1,230 @@
+package tagger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sebastianxyzsss/tardigrade/logger"
+	"github.com/sebastianxyzsss/tardigrade/parser"
+	"github.com/sebastianxyzsss/tardigrade/reader"
+)
+
+var ll = logger.SetupLog()
+
+// how a tag is used across the content
+type TagInfo struct {
+	Tag       string
+	Direct    int      // commands with the tag in their own description
+	Inherited int      // commands with the tag from one of their groups
+	Files     []string // files where the tag is written
+}
+
+func (t TagInfo) Commands() int {
+	return t.Direct + t.Inherited
+}
+
+// a command with a tag, and if the tag is its own
+type Carrier struct {
+	Element *parser.Element
+	Direct  bool
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// if one of the tags is selected by the filter, with its namespaces and
+// aliases, like the filter of the chooser
+func hasMatchingTag(tags []string, filter string) bool {
+	for _, t := range tags {
+		if parser.TagMatches(t, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// the file part of a file:line source
+func sourceFile(source string) string {
+	if i := strings.LastIndex(source, ":"); i > 0 {
+		return source[:i]
+	}
+	return source
+}
+
+// every tag in the tree, sorted by name
+func List(root *parser.Element) []TagInfo {
+	infos := make(map[string]*TagInfo)
+	files := make(map[string]map[string]bool)
+
+	info := func(tag string) *TagInfo {
+		if _, ok := infos[tag]; !ok {
+			infos[tag] = &TagInfo{Tag: tag, Files: make([]string, 0)}
+			files[tag] = make(map[string]bool)
+		}
+		return infos[tag]
+	}
+
+	parser.Walk(root, func(element *parser.Element) {
+		for _, tag := range element.DirectTags {
+			info(tag)
+			if element.Source != "" {
+				files[tag][sourceFile(element.Source)] = true
+			}
+		}
+		if !element.IsCommand {
+			return
+		}
+		counted := make(map[string]bool)
+		for _, tag := range element.Tags {
+			if counted[tag] {
+				continue
+			}
+			counted[tag] = true
+			if hasTag(element.DirectTags, tag) {
+				info(tag).Direct++
+			} else {
+				info(tag).Inherited++
+			}
+		}
+	})
+
+	list := make([]TagInfo, 0, len(infos))
+	for tag, tagInfo := range infos {
+		for file := range files[tag] {
+			tagInfo.Files = append(tagInfo.Files, file)
+		}
+		sort.Strings(tagInfo.Files)
+		list = append(list, *tagInfo)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Tag < list[j].Tag
+	})
+	return list
+}
+
+// the commands that have the tag, own or inherited, the tags under it and its
+// aliases included, so env finds @env/prod
+func Carriers(root *parser.Element, tag string) []Carrier {
+	carriers := make([]Carrier, 0)
+	parser.Walk(root, func(element *parser.Element) {
+		if element.IsCommand && hasMatchingTag(element.Tags, tag) {
+			carriers = append(carriers, Carrier{Element: element, Direct: hasMatchingTag(element.DirectTags, tag)})
+		}
+	})
+	return carriers
+}
+
+// replaces the markers of tag in the descriptions of content, the part after
+// ^ of each line. Like tg tags show, the tags under it and the tags written
+// with an alias or in another case are replaced too, so renaming env to stage
+// turns @env/prod into @stage/prod. An empty newTag removes the marker and the
+// space before it, and the ^ when nothing but the closing quote or colon of
+// the line is left after it. It returns the new content and how many markers
+// were replaced.
+func rewrite(content string, tag string, newTag string) (string, int) {
+	count := 0
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		start := strings.Index(line, "^")
+		if start < 0 {
+			continue
+		}
+		description := line[start+1:]
+
+		var b strings.Builder
+		last, found := 0, 0
+		for _, loc := range parser.TagMarkers(description) {
+			written := description[loc[2]:loc[3]]
+			if newTag == "" {
+				if !parser.TagMatches(written, tag) {
+					continue
+				}
+				b.WriteString(strings.TrimRight(description[last:loc[0]], " \t"))
+			} else {
+				rest, ok := parser.TagRest(written, tag)
+				if !ok {
+					continue
+				}
+				b.WriteString(description[last:loc[0]] + "@" + newTag + rest)
+			}
+			found++
+			last = loc[1]
+		}
+		if found == 0 {
+			continue
+		}
+		count += found
+		b.WriteString(description[last:])
+		description = b.String()
+
+		if rest := strings.TrimSpace(description); strings.Trim(rest, `"':`) == "" {
+			lines[i] = strings.TrimRight(line[:start], " \t") + rest
+		} else {
+			lines[i] = line[:start+1] + description
+		}
+	}
+	return strings.Join(lines, "\n"), count
+}
+
+// rewrites the tag in every writable content file, versioning the change.
+// It returns how many markers were changed.
+func change(tag string, newTag string, message string) (int, error) {
+	total := 0
+	changed := make([]string, 0)
+	for _, fileName := range reader.WritableContentFiles() {
+		content := reader.GetFileAsString(fileName)
+		if content == nil {
+			continue
+		}
+		if _, count := rewrite(*content, tag, newTag); count == 0 {
+			continue
+		}
+
+		count := 0
+		err := reader.UpdateFile(fileName, func(current *string) (string, error) {
+			if current == nil {
+				return "", fmt.Errorf("%s is gone", fileName)
+			}
+			var rewritten string
+			rewritten, count = rewrite(*current, tag, newTag)
+			return rewritten, nil
+		})
+		if err != nil {
+			return total, fmt.Errorf("unable to rewrite %s: %w", fileName, err)
+		}
+		ll.Info().Msg(fmt.Sprintf("%s: %d changed", fileName, count))
+		total += count
+		changed = append(changed, fileName)
+	}
+
+	if total == 0 {
+		return 0, fmt.Errorf("the tag %s is not written in %s", tag, strings.Join(reader.WritableContentFiles(), ", "))
+	}
+
+	err := reader.CommitChange(message, changed...)
+	if err != nil {
+		ll.Warn().Err(err).Msg("unable to version the change")
+	}
+	return total, nil
+}
+
+func Rename(tag string, newTag string) (int, error) {
+	if !parser.IsValidTag(newTag) {
+		return 0, fmt.Errorf("invalid tag name %q", newTag)
+	}
+	if !parser.IsValidTag(tag) {
+		return 0, fmt.Errorf("invalid tag name %q", tag)
+	}
+	return change(tag, newTag, "tags: renamed "+tag+" to "+newTag)
+}
+
+func Delete(tag string) (int, error) {
+	return change(tag, "", "tags: deleted "+tag)
+}
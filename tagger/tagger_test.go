@@ -0,0 +1,79 @@
+package tagger
+
+import (
+	"testing"
+
+	"github.com/sebastianxyzsss/tardigrade/parser"
+)
+
+func TestRewrite(t *testing.T) {
+	parser.SetTagAliases(map[string][]string{"kubernetes": {"k8s"}})
+	defer parser.SetTagAliases(nil)
+
+	tests := []struct {
+		name    string
+		content string
+		tag     string
+		newTag  string
+		want    string
+		count   int
+	}{
+		{"rename", "  - ls ^ list @old", "old", "new", "  - ls ^ list @new", 1},
+		{"rename keeps the dot", "  - ls ^ list @old.", "old", "new", "  - ls ^ list @new.", 1},
+		{"rename a namespace", "  - ls ^ @env/prod @env", "env", "stage", "  - ls ^ @stage/prod @stage", 2},
+		{"rename by alias", "  - kubectl ^ @k8s/pods", "kubernetes", "kube", "  - kubectl ^ @kube/pods", 1},
+		{"rename another case", "  - ls ^ @Old", "old", "new", "  - ls ^ @new", 1},
+		{"other tags are kept", "  - ls ^ @older @environment", "old", "new", "  - ls ^ @older @environment", 0},
+		{"not before the ^", "  - echo @old ^ x", "old", "new", "  - echo @old ^ x", 0},
+		{"delete", "  - ls ^ list @old @keep", "old", "", "  - ls ^ list @keep", 1},
+		{"delete a namespace", "  - ls ^ list @env/prod @env/dev", "env", "", "  - ls ^ list", 2},
+		{"delete the only tag", "  - ls ^ @old", "old", "", "  - ls", 1},
+		{"delete the only tag of a group", "  group ^ @old:", "old", "", "  group:", 1},
+		{"delete the only tag of a quoted item", `  - "echo ^ @old"`, "old", "", `  - "echo"`, 1},
+		{"delete the only tag of a quoted group", `  "group ^ @old":`, "old", "", `  "group":`, 1},
+		{"delete with a star", "  - ls ^ @env1 @env2 @x", "env*", "", "  - ls ^ @x", 2},
+		{"lines", "a ^ @old:\n  - ls\n  - cd ^ @old", "old", "", "a:\n  - ls\n  - cd", 2},
+	}
+
+	for _, test := range tests {
+		got, count := rewrite(test.content, test.tag, test.newTag)
+		if got != test.want || count != test.count {
+			t.Errorf("%s: rewrite(%q, %q, %q) = %q, %d, want %q, %d", test.name, test.content, test.tag, test.newTag, got, count, test.want, test.count)
+		}
+	}
+}
+
+func TestCarriers(t *testing.T) {
+	parser.SetTagAliases(map[string][]string{"kubernetes": {"k8s"}})
+	defer parser.SetTagAliases(nil)
+
+	root := parser.NewElement("root", false, nil)
+	parser.RecurseMap(map[interface{}]interface{}{
+		"deploy ^ @env": []interface{}{"make prod ^ @env/prod", "make dev"},
+		"cluster":       []interface{}{"kubectl get pods ^ @k8s", "ls"},
+	}, root)
+
+	tests := []struct {
+		tag    string
+		direct map[string]bool
+	}{
+		{"env", map[string]bool{"make prod": true, "make dev": false}},
+		{"env/prod", map[string]bool{"make prod": true}},
+		{"kubernetes", map[string]bool{"kubectl get pods": true}},
+		{"docker", map[string]bool{}},
+	}
+
+	for _, test := range tests {
+		carriers := Carriers(root, test.tag)
+		if len(carriers) != len(test.direct) {
+			t.Errorf("Carriers(%q) found %d commands, want %d", test.tag, len(carriers), len(test.direct))
+			continue
+		}
+		for _, carrier := range carriers {
+			direct, ok := test.direct[carrier.Element.Content]
+			if !ok || direct != carrier.Direct {
+				t.Errorf("Carriers(%q) found %q direct %v", test.tag, carrier.Element.Content, carrier.Direct)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"regexp"
+)
+
+// a secret placeholder looks like <name!secret>, other angle brackets are left
+// as they are written
+var placeholderRegex = regexp.MustCompile(`<([A-Za-z0-9_.-]+)!secret>`)
+
+type Placeholder struct {
+	Token string
+	Name  string
+}
+
+// returns the secret placeholders found in content, in order and without
+// repeats
+func FindPlaceholders(content string) []Placeholder {
+	placeholders := make([]Placeholder, 0)
+	seen := make(map[string]bool)
+	for _, groups := range placeholderRegex.FindAllStringSubmatch(content, -1) {
+		if seen[groups[0]] {
+			continue
+		}
+		seen[groups[0]] = true
+		placeholders = append(placeholders, Placeholder{
+			Token: groups[0],
+			Name:  groups[1],
+		})
+	}
+	return placeholders
+}
+
+// replaces every placeholder token found in values, others are left
+// untouched; it is done in one pass so a value is never replaced again
+func ReplacePlaceholders(content string, values map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(content, func(token string) string {
+		if value, ok := values[token]; ok {
+			return value
+		}
+		return token
+	})
+}
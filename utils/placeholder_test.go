@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindPlaceholders(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []Placeholder
+	}{
+		{"ls -la", []Placeholder{}},
+		{"echo <name>", []Placeholder{}},
+		{"curl -H 'token: <token!secret>' <url!secret>", []Placeholder{
+			{Token: "<token!secret>", Name: "token"},
+			{Token: "<url!secret>", Name: "url"},
+		}},
+		{"<a!secret> <a!secret>", []Placeholder{{Token: "<a!secret>", Name: "a"}}},
+		{"<api_key.v2-x!secret>", []Placeholder{{Token: "<api_key.v2-x!secret>", Name: "api_key.v2-x"}}},
+		{"<two words!secret>", []Placeholder{}},
+	}
+
+	for _, test := range tests {
+		if got := FindPlaceholders(test.content); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("FindPlaceholders(%q) = %v, want %v", test.content, got, test.want)
+		}
+	}
+}
+
+func TestReplacePlaceholders(t *testing.T) {
+	tests := []struct {
+		content string
+		values  map[string]string
+		want    string
+	}{
+		{"login <user!secret>", map[string]string{"<user!secret>": "bob"}, "login bob"},
+		{"<a!secret> <a!secret>", map[string]string{"<a!secret>": "x"}, "x x"},
+		{"<a!secret> <b!secret>", map[string]string{"<a!secret>": "x"}, "x <b!secret>"},
+		{"echo <name> <a!secret>", map[string]string{"<a!secret>": "x"}, "echo <name> x"},
+		// values are never replaced again, whatever the order of the map
+		{"<a!secret> <b!secret>", map[string]string{"<a!secret>": "<b!secret>", "<b!secret>": "<a!secret>"}, "<b!secret> <a!secret>"},
+		{"<a!secret>", map[string]string{"<a!secret>": "$1 \\ &"}, "$1 \\ &"},
+	}
+
+	for _, test := range tests {
+		// maps are walked in a random order, a few runs show it does not matter
+		for i := 0; i < 10; i++ {
+			if got := ReplacePlaceholders(test.content, test.values); got != test.want {
+				t.Errorf("ReplacePlaceholders(%q, %v) = %q, want %q", test.content, test.values, got, test.want)
+				break
+			}
+		}
+	}
+}